@@ -0,0 +1,248 @@
+// Package cache keeps checkouts of git template sources on disk, so every
+// distinct repository and ref is fetched once and boilerplate renders
+// templates from a local directory.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"boilerplate-compose/lock"
+)
+
+// DirEnvVar overrides the default cache directory.
+const DirEnvVar = "BOILERPLATE_COMPOSE_CACHE_DIR"
+
+// DefaultDir returns the cache directory used when none is given: the
+// directory in DirEnvVar, or boilerplate-compose in the user cache
+// directory.
+func DefaultDir() (string, error) {
+	if dir := os.Getenv(DirEnvVar); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the user cache directory, set %s: %w", DirEnvVar, err)
+	}
+	return filepath.Join(dir, "boilerplate-compose"), nil
+}
+
+// Cache holds checkouts of git repositories by commit. Its layout is
+//
+//	git/<repository key>/<commit>/     files of the commit, without .git
+//	git/<repository key>/refs/<ref>    commit the ref last resolved to
+//
+// where the repository key is derived from the repository URL. Checkouts
+// never change once written, since a commit fixes its content. A Cache is
+// safe for concurrent use; templates using the same repository and ref wait
+// for each other, so it is resolved and fetched once.
+type Cache struct {
+	dir     string
+	offline bool
+
+	mu sync.Mutex
+	// locks serializes the work on each repository and ref
+	locks map[string]*sync.Mutex
+	// commits holds the commit each repository and ref resolved to in this
+	// run
+	commits map[string]string
+}
+
+// New returns a cache in dir, or in DefaultDir when dir is empty. An offline
+// cache never contacts a repository: refs resolve to the commit they last
+// resolved to, and sources that are not in the cache are an error.
+func New(dir string, offline bool) *Cache {
+	return &Cache{
+		dir:     dir,
+		offline: offline,
+		locks:   make(map[string]*sync.Mutex),
+		commits: make(map[string]string),
+	}
+}
+
+// Path returns the local directory boilerplate should render a template
+// from. Git sources are fetched into the cache when their commit is not in
+// it yet. Local templates are returned as they are, as are other remote
+// sources unless the cache is offline, which makes them an error.
+func (c *Cache) Path(ctx context.Context, templateURL string) (string, error) {
+	source := lock.ParseSource(templateURL)
+	switch source.Kind {
+	case lock.SourceLocal:
+		return templateURL, nil
+	case lock.SourceOther:
+		if c.offline {
+			return "", fmt.Errorf("%s cannot be cached, so it cannot be used offline", templateURL)
+		}
+		return templateURL, nil
+	}
+
+	unlock, err := c.lock(source)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	commit, err := c.commit(ctx, source)
+	if err != nil {
+		return "", err
+	}
+
+	revision := source.Ref
+	if revision != commit {
+		revision += " (" + shortCommit(commit) + ")"
+	}
+
+	checkout := filepath.Join(c.repoDir(source), commit)
+	if _, err := os.Stat(checkout); err == nil {
+		log.Printf("Using cached %s at %s", source.Repository, revision)
+	} else if c.offline {
+		return "", notCached(source)
+	} else {
+		log.Printf("Fetching %s at %s", source.Repository, revision)
+		if err := fetch(ctx, source.Repository, commit, checkout); err != nil {
+			return "", err
+		}
+	}
+
+	path := filepath.Join(checkout, filepath.FromSlash(source.Subdir))
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("%s not found in %s at %s", source.Subdir, source.Repository, source.Ref)
+	}
+	return path, nil
+}
+
+// Revision returns the commit a git template URL resolves to, without
+// fetching it. Other sources have no revision.
+func (c *Cache) Revision(ctx context.Context, templateURL string) (string, error) {
+	source := lock.ParseSource(templateURL)
+	if source.Kind != lock.SourceGit {
+		return "", fmt.Errorf("%s is not a git source", templateURL)
+	}
+
+	unlock, err := c.lock(source)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	commit, err := c.commit(ctx, source)
+	if err != nil {
+		return "", err
+	}
+	return source.Repository + "@" + commit, nil
+}
+
+// lock waits for other work on the repository and ref of source to finish
+// and returns the function that releases it.
+func (c *Cache) lock(source lock.Source) (func(), error) {
+	c.mu.Lock()
+	if c.dir == "" {
+		dir, err := DefaultDir()
+		if err != nil {
+			c.mu.Unlock()
+			return nil, err
+		}
+		c.dir = dir
+	}
+	key := sourceKey(source)
+	m, ok := c.locks[key]
+	if !ok {
+		m = &sync.Mutex{}
+		c.locks[key] = m
+	}
+	c.mu.Unlock()
+
+	m.Lock()
+	return m.Unlock, nil
+}
+
+// commit returns the commit the ref of source points at, resolving it once
+// per run. The caller must hold the lock of source.
+func (c *Cache) commit(ctx context.Context, source lock.Source) (string, error) {
+	key := sourceKey(source)
+	c.mu.Lock()
+	commit, ok := c.commits[key]
+	c.mu.Unlock()
+	if ok {
+		return commit, nil
+	}
+
+	refFile := filepath.Join(c.repoDir(source), "refs", url.PathEscape(source.Ref))
+	if c.offline {
+		data, err := os.ReadFile(refFile)
+		if errors.Is(err, fs.ErrNotExist) && isCommit(source.Ref) {
+			data, err = []byte(source.Ref), nil
+		}
+		if err != nil {
+			return "", notCached(source)
+		}
+		commit = strings.TrimSpace(string(data))
+	} else {
+		var err error
+		if commit, err = lock.ResolveCommit(ctx, source); err != nil {
+			return "", err
+		}
+		if err := writeRef(refFile, commit); err != nil {
+			return "", err
+		}
+	}
+
+	c.mu.Lock()
+	c.commits[key] = commit
+	c.mu.Unlock()
+	return commit, nil
+}
+
+func (c *Cache) repoDir(source lock.Source) string {
+	return filepath.Join(c.dir, "git", repositoryKey(source.Repository))
+}
+
+func notCached(source lock.Source) error {
+	return fmt.Errorf("%s at %s is not in the cache, run once without -offline to fetch it", source.Repository, source.Ref)
+}
+
+func sourceKey(source lock.Source) string {
+	return source.Repository + "\x00" + source.Ref
+}
+
+// repositoryKey returns the directory name of a repository in the cache.
+func repositoryKey(repository string) string {
+	sum := sha256.Sum256([]byte(repository))
+	return hex.EncodeToString(sum[:8])
+}
+
+// writeRef records the commit a ref resolved to, for offline runs.
+func writeRef(path, commit string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to write to the cache: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(commit+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write to the cache: %w", err)
+	}
+	return nil
+}
+
+func isCommit(ref string) bool {
+	if len(ref) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(ref)
+	return err == nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
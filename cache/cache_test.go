@@ -0,0 +1,216 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"boilerplate-compose/executor"
+)
+
+// gitRepo creates a repository with the given files committed on main and
+// returns its path.
+func gitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	runGit(t, dir, "config", "user.name", "test")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	commitFiles(t, dir, files)
+	return dir
+}
+
+func commitFiles(t *testing.T, dir string, files map[string]string) string {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "update")
+	return runGit(t, dir, "rev-parse", "HEAD")
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCache_Path(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	repo := gitRepo(t, map[string]string{
+		"web/boilerplate.yml": "web v1\n",
+		"api/boilerplate.yml": "api v1\n",
+	})
+	first := runGit(t, repo, "rev-parse", "HEAD")
+	cacheDir := t.TempDir()
+	ctx := context.Background()
+	repoURL := "git::file://" + repo
+
+	path := func(t *testing.T, cache *Cache, templateURL string) string {
+		t.Helper()
+		path, err := cache.Path(ctx, templateURL)
+		if err != nil {
+			t.Fatalf("Path(%q) error = %v", templateURL, err)
+		}
+		return path
+	}
+
+	t.Run("fetches each repository and ref once", func(t *testing.T) {
+		cache := New(cacheDir, false)
+		checkout := filepath.Join(cacheDir, "git", repositoryKey("file://"+repo), first)
+		if got := path(t, cache, repoURL+"//web?ref=main"); got != filepath.Join(checkout, "web") {
+			t.Errorf("web path = %q, want it in %s", got, checkout)
+		}
+		if got := readFile(t, filepath.Join(path(t, cache, repoURL+"//api?ref=main"), "boilerplate.yml")); got != "api v1\n" {
+			t.Errorf("unexpected api template content %q", got)
+		}
+		if got := path(t, cache, "./templates/local"); got != "./templates/local" {
+			t.Errorf("local path = %q, want it unchanged", got)
+		}
+		if got := path(t, cache, "https://example.com/templates.zip"); got != "https://example.com/templates.zip" {
+			t.Errorf("other source = %q, want it unchanged", got)
+		}
+		if _, err := os.Stat(filepath.Join(checkout, ".git")); !os.IsNotExist(err) {
+			t.Error("expected the checkout to have no .git directory")
+		}
+
+		entries, err := os.ReadDir(filepath.Dir(checkout))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 2 {
+			t.Errorf("expected one checkout and the refs directory, got %v", entries)
+		}
+
+		revision, err := cache.Revision(ctx, repoURL+"//web?ref=main")
+		if err != nil || revision != "file://"+repo+"@"+first {
+			t.Errorf("Revision() = %q, %v, want the first commit", revision, err)
+		}
+	})
+
+	second := commitFiles(t, repo, map[string]string{"web/boilerplate.yml": "web v2\n"})
+
+	t.Run("offline uses the last resolved commit", func(t *testing.T) {
+		web := path(t, New(cacheDir, true), repoURL+"//web?ref=main")
+		if got := readFile(t, filepath.Join(web, "boilerplate.yml")); got != "web v1\n" {
+			t.Errorf("expected the cached commit, got %q", got)
+		}
+	})
+
+	t.Run("online follows the ref", func(t *testing.T) {
+		if web := path(t, New(cacheDir, false), repoURL+"//web?ref=main"); !strings.Contains(web, second) {
+			t.Errorf("expected a checkout of %s, got %s", second, web)
+		}
+	})
+
+	t.Run("pinned commits", func(t *testing.T) {
+		web := path(t, New(cacheDir, true), repoURL+"//web?ref="+first)
+		if got := readFile(t, filepath.Join(web, "boilerplate.yml")); got != "web v1\n" {
+			t.Errorf("expected the pinned commit, got %q", got)
+		}
+	})
+
+	errorTests := []struct {
+		name        string
+		templateURL string
+		offline     bool
+		expected    string
+	}{
+		{"ref not cached", repoURL + "//web?ref=v9", true, "is not in the cache, run once without -offline"},
+		{"other source offline", "https://example.com/templates.zip", true, "cannot be used offline"},
+		{"missing subdir", repoURL + "//docs?ref=main", false, "docs not found in file://" + repo},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(cacheDir, tt.offline).Path(ctx, tt.templateURL)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected an error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+// argsExecutor records the arguments it is run with
+type argsExecutor struct {
+	args [][]string
+}
+
+func (e *argsExecutor) Execute(ctx context.Context, args []string, templateName string) error {
+	e.args = append(e.args, args)
+	return nil
+}
+
+func (e *argsExecutor) CheckBoilerplateAvailable() error {
+	return nil
+}
+
+func TestExecutor(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	repo := gitRepo(t, map[string]string{"web/boilerplate.yml": "web\n"})
+	ctx := context.Background()
+
+	t.Run("renders from the cache", func(t *testing.T) {
+		next := &argsExecutor{}
+		exec := NewExecutor(New(t.TempDir(), false), next)
+		templateURL := "git::file://" + repo + "//web?ref=main"
+		args := []string{"--template-url", templateURL, "--output-folder", "out"}
+		if err := exec.Execute(ctx, args, "web"); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+
+		got := next.args[0][1]
+		if got == templateURL || readFile(t, filepath.Join(got, "boilerplate.yml")) != "web\n" {
+			t.Errorf("expected boilerplate to get the checkout, got %q", got)
+		}
+		if args[1] != templateURL {
+			t.Error("expected the arguments of the job to be left unchanged")
+		}
+	})
+
+	t.Run("git failures are retried", func(t *testing.T) {
+		exec := NewExecutor(New(t.TempDir(), false), &argsExecutor{})
+		args := []string{"--template-url", "git::file://" + filepath.Join(repo, "missing") + "//web", "--output-folder", "out"}
+		attempts, err := executor.ExecuteWithRetry(ctx, exec, executor.RetryPolicy{Attempts: 2}, 0, args, "web")
+
+		var cmdErr *executor.CommandError
+		if !errors.As(err, &cmdErr) || cmdErr.Command != "git" {
+			t.Fatalf("expected a git command error, got %v", err)
+		}
+		if len(attempts) != 2 {
+			t.Errorf("expected 2 attempts, got %d", len(attempts))
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"boilerplate-compose/executor"
+	"boilerplate-compose/lock"
+)
+
+// Executor renders templates with another executor after replacing their
+// git template URL with the path of its checkout in the cache. Fetching is
+// part of running the template, so it is covered by the template's
+// timeout and retry policy, runs in parallel with other templates and is
+// skipped entirely in dry-run mode.
+type Executor struct {
+	cache *Cache
+	next  executor.Executor
+}
+
+// NewExecutor returns an executor that fetches template sources through
+// cache before rendering them with next.
+func NewExecutor(cache *Cache, next executor.Executor) *Executor {
+	return &Executor{cache: cache, next: next}
+}
+
+func (e *Executor) Execute(ctx context.Context, args []string, templateName string) error {
+	args = append([]string(nil), args...)
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] != "--template-url" {
+			continue
+		}
+		path, err := e.cache.Path(ctx, args[i+1])
+		if err != nil {
+			return commandError(templateName, err)
+		}
+		args[i+1] = path
+	}
+	return e.next.Execute(ctx, args, templateName)
+}
+
+func (e *Executor) CheckBoilerplateAvailable() error {
+	return e.next.CheckBoilerplateAvailable()
+}
+
+// Revision returns the commit a git template URL resolves to, so
+// incremental runs can tell when a remote template changed.
+func (e *Executor) Revision(ctx context.Context, templateURL string) (string, error) {
+	return e.cache.Revision(ctx, templateURL)
+}
+
+// commandError reports a failed git command as a *executor.CommandError, so
+// retry policies apply to it like to boilerplate failures.
+func commandError(templateName string, err error) error {
+	var gitErr *lock.GitError
+	if !errors.As(err, &gitErr) {
+		return err
+	}
+	return &executor.CommandError{
+		TemplateName: templateName,
+		Command:      "git",
+		ExitCode:     gitErr.ExitCode,
+		Stderr:       gitErr.Stderr,
+		Err:          err,
+	}
+}
+
+var _ executor.Executor = (*Executor)(nil)
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"boilerplate-compose/lock"
+)
+
+// fetch writes the files of a commit of repository to dir. The commit is
+// fetched shallowly into a temporary directory next to dir, which is then
+// renamed into place, so an interrupted fetch never leaves a partial
+// checkout behind. git failures are returned as *lock.GitError.
+func fetch(ctx context.Context, repository, commit, dir string) error {
+	parent := filepath.Dir(dir)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.MkdirTemp(parent, ".fetch-")
+	if err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	steps := [][]string{
+		{"init", "-q"},
+		{"fetch", "-q", "--depth", "1", repository, commit},
+		{"checkout", "-q", "--detach", "FETCH_HEAD"},
+	}
+	for _, args := range steps {
+		if _, err := lock.RunGit(ctx, tmp, args...); err != nil {
+			return fmt.Errorf("failed to fetch %s at %s: %w", repository, commit, err)
+		}
+	}
+	if err := os.RemoveAll(filepath.Join(tmp, ".git")); err != nil {
+		return fmt.Errorf("failed to fetch %s at %s: %w", repository, commit, err)
+	}
+
+	if err := os.Rename(tmp, dir); err != nil {
+		// Another run may have fetched the same commit in the meantime
+		if _, statErr := os.Stat(dir); statErr == nil {
+			return nil
+		}
+		return fmt.Errorf("failed to write to the cache: %w", err)
+	}
+	return nil
+}
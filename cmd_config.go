@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// runConfig implements the config command, which prints the compose file
+// after interpolation, includes and extends have been applied
+func runConfig(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	var load loadFlags
+	load.register(fs)
+	format := fs.String("format", "yaml", "Output format: yaml or json")
+	listTemplates := fs.Bool("templates", false, "Print only the template names, one per line")
+	fs.BoolVar(listTemplates, "services", false, "Alias for -templates")
+	resolvePaths := fs.Bool("resolve-paths", false, "Print output folders, local template URLs and var files as absolute paths")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if *format != "yaml" && *format != "json" {
+		return fmt.Errorf("invalid format %q (expected yaml or json)", *format)
+	}
+
+	cfg, configPath, err := load.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if *listTemplates {
+		for _, name := range cfg.TemplateNames() {
+			fmt.Fprintln(stdout, name)
+		}
+		return nil
+	}
+
+	cfg = cfg.Normalized()
+	if *resolvePaths {
+		if cfg, err = cfg.WithResolvedPaths(configPath); err != nil {
+			return err
+		}
+	}
+
+	if *format == "json" {
+		data, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode config: %w", err)
+		}
+		_, err = fmt.Fprintf(stdout, "%s\n", data)
+		return err
+	}
+
+	encoder := yaml.NewEncoder(stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	return encoder.Close()
+}
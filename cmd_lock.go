@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"strings"
+
+	"boilerplate-compose/config"
+	"boilerplate-compose/lock"
+)
+
+// runLock implements the lock command, which writes the lock file next to
+// the compose file. Entries that are still current are kept, so locking
+// again does not move templates to newer commits; use update for that.
+func runLock(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	var load loadFlags
+	load.register(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	return writeLock(load, false, nil, stdout)
+}
+
+// runUpdate implements the update command, which resolves every template,
+// or the ones named, again and writes the result to the lock file
+func runUpdate(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	var load loadFlags
+	load.register(fs)
+
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+
+	return writeLock(load, true, names, stdout)
+}
+
+// writeLock resolves the templates of the compose file and saves the lock
+// file. Missing and stale entries are always resolved. With update set, the
+// templates named, or every template when none are, are resolved again even
+// when their entry is current.
+func writeLock(load loadFlags, update bool, names []string, stdout io.Writer) error {
+	cfg, configPath, err := load.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	lockPath := lock.PathFor(configPath)
+	lockFile, err := lock.Load(lockPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		lockFile = lock.New()
+	} else if err != nil {
+		return err
+	}
+
+	refresh := make(map[string]bool)
+	var unknown []string
+	for _, name := range names {
+		if _, ok := cfg.Templates[name]; !ok {
+			unknown = append(unknown, name)
+		}
+		refresh[name] = true
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown template(s): %s", strings.Join(unknown, ", "))
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	for _, name := range lockFile.Prune(cfg) {
+		fmt.Fprintf(stdout, "Removed %s\n", name)
+	}
+
+	for _, name := range cfg.TemplateNames() {
+		template := cfg.Templates[name]
+		forced := update && (len(names) == 0 || refresh[name])
+		if !forced && lockFile.IsCurrent(name, template) {
+			continue
+		}
+
+		entry, err := lock.Resolve(ctx, template)
+		if err != nil {
+			return fmt.Errorf("failed to lock template '%s': %w", name, err)
+		}
+
+		previous, existed := lockFile.Templates[name]
+		lockFile.Templates[name] = entry
+		switch {
+		case !existed:
+			fmt.Fprintf(stdout, "Locked %s at %s\n", name, describeEntry(entry))
+		case previous != entry:
+			fmt.Fprintf(stdout, "Updated %s from %s to %s\n", name, describeEntry(previous), describeEntry(entry))
+		}
+	}
+
+	if err := lockFile.Save(lockPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Wrote %s (%d templates)\n", lockPath, len(lockFile.Templates))
+	return nil
+}
+
+// describeEntry returns what a lock entry pins its template to
+func describeEntry(entry lock.Entry) string {
+	switch {
+	case entry.Commit != "":
+		return "commit " + entry.Commit
+	case entry.Hash != "":
+		return entry.Hash
+	}
+	return entry.TemplateURL
+}
+
+// applyLock pins the templates of cfg to the commits in the lock file next
+// to configPath. Missing or stale entries are warnings, or errors when
+// frozen is set; without a lock file templates are left as they are unless
+// frozen is set.
+func applyLock(cfg *config.ComposeConfig, configPath string, frozen bool) error {
+	lockPath := lock.PathFor(configPath)
+	lockFile, err := lock.Load(lockPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		if frozen {
+			return fmt.Errorf("-frozen is set but there is no lock file at %s. Run 'boilerplate-compose lock' to create it", lockPath)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	problems := lockFile.Check(cfg)
+	if frozen && len(problems) > 0 {
+		lines := make([]string, len(problems))
+		for i, problem := range problems {
+			lines[i] = "\n  " + problem.String()
+		}
+		return fmt.Errorf("-frozen is set but %s is out of date. Run 'boilerplate-compose lock' to update it:%s", lockPath, strings.Join(lines, ""))
+	}
+	for _, problem := range problems {
+		log.Printf("Warning: %s is out of date: %s", lockPath, problem)
+	}
+
+	lockFile.Apply(cfg)
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"boilerplate-compose/config"
+	"boilerplate-compose/processor"
+)
+
+// runList implements the ls command, which lists templates in the order
+// up would run them. Every template is listed whatever its profiles, unless
+// -profile restricts the list to the templates enabled by those profiles.
+func runList(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	var load loadFlags
+	load.register(fs)
+	quiet := fs.Bool("q", false, "Only print template names")
+	var profiles stringList
+	fs.Var(&profiles, "profile", "Only list templates enabled by this template profile, may be repeated (lists every template by default; unrelated to -env-profile)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	cfg, configPath, err := load.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	templateProcessor := processor.NewTemplateProcessor(cfg, configPath)
+	if len(profiles) > 0 {
+		templateProcessor.SetProfiles(activeProfiles(profiles))
+	} else {
+		templateProcessor.SetProfiles([]string{config.AllProfiles})
+	}
+
+	jobs, err := templateProcessor.ExecutionPlan()
+	if err != nil {
+		return err
+	}
+
+	if *quiet {
+		for _, job := range jobs {
+			fmt.Fprintln(stdout, job.Name)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTEMPLATE URL\tOUTPUT FOLDER\tDEPENDS ON\tPROFILES")
+	for _, job := range jobs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", job.Name, job.Template.TemplateURL, job.OutputPath,
+			strings.Join(job.Template.DependsOn, ", "), strings.Join(job.Template.Profiles, ", "))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"boilerplate-compose/processor"
+)
+
+// runPlan implements the plan command, which renders the templates into a
+// staging area and shows how their output folders would change
+func runPlan(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	var flags runFlags
+	flags.register(fs)
+	noDiff := fs.Bool("no-diff", false, "Only list the files that would change")
+
+	selection, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+
+	orchestrator, err := flags.orchestrator(selection, processor.Options{})
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	return runPlanWith(ctx, orchestrator, stdout, !*noDiff)
+}
+
+// runPlanWith renders a plan and writes the changes it would make
+func runPlanWith(ctx context.Context, orchestrator *processor.Orchestrator, stdout io.Writer, showDiff bool) error {
+	changes, err := orchestrator.Plan(ctx)
+	if err != nil {
+		return fmt.Errorf("planning failed: %w", err)
+	}
+
+	processor.WritePlan(stdout, changes, showDiff)
+	return nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"boilerplate-compose/cache"
+	"boilerplate-compose/config"
+	"boilerplate-compose/executor"
+	"boilerplate-compose/processor"
+)
+
+// runUp implements the up command, which renders every template or the
+// ones selected by name
+func runUp(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	var flags runFlags
+	flags.register(fs)
+	var dryRun dryRunFlag
+	fs.Var(&dryRun, "dry-run", "Show what would be executed without running; -dry-run=diff renders to a staging area and shows the changes, like plan")
+	keepGoing := fs.Bool("keep-going", false, "Keep running templates that do not depend on a failed one")
+	force := fs.Bool("force", false, "Run every template, even those that are up to date")
+	var forceTemplates stringList
+	fs.Var(&forceTemplates, "force-template", "Run this template even when it is up to date, may be repeated")
+	var reports stringList
+	fs.Var(&reports, "report", "Write a report as format=path, where format is json or junit, may be repeated")
+
+	// Remaining arguments select templates by name or glob
+	selection, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var reportFiles []executor.Report
+	for _, spec := range reports {
+		report, err := executor.ParseReport(spec)
+		if err != nil {
+			return err
+		}
+		reportFiles = append(reportFiles, report)
+	}
+
+	orchestrator, err := flags.orchestrator(selection, processor.Options{
+		DryRun:         dryRun == dryRunCommands,
+		KeepGoing:      *keepGoing,
+		Reports:        reportFiles,
+		Incremental:    true,
+		Force:          *force,
+		ForceTemplates: forceTemplates,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	if dryRun == dryRunDiff {
+		return runPlanWith(ctx, orchestrator, stdout, true)
+	}
+
+	if err := orchestrator.ProcessContext(ctx); err != nil {
+		return fmt.Errorf("processing failed: %w", err)
+	}
+
+	if dryRun == dryRunCommands {
+		fmt.Fprintln(stdout, "\nDry run completed. Use without -dry-run to execute.")
+	} else {
+		fmt.Fprintln(stdout, "\nAll templates processed successfully.")
+	}
+
+	return nil
+}
+
+// runFlags are the flags that select templates and control how they are
+// rendered. They are shared by up and plan.
+type runFlags struct {
+	load            loadFlags
+	engine          string
+	boilerplatePath string
+	verbose         bool
+	parallel        int
+	timeout         time.Duration
+	collisionPolicy string
+	frozen          bool
+	offline         bool
+	cacheDir        string
+	exclude         stringList
+	noDeps          bool
+	profiles        stringList
+}
+
+func (f *runFlags) register(fs *flag.FlagSet) {
+	f.load.register(fs)
+	fs.StringVar(&f.engine, "engine", string(executor.EngineCLI), "How templates are rendered: cli runs the boilerplate binary, library runs boilerplate in-process")
+	fs.StringVar(&f.boilerplatePath, "boilerplate-path", "", "Path to boilerplate CLI (defaults to PATH lookup)")
+	fs.BoolVar(&f.verbose, "verbose", false, "Show detailed output from boilerplate commands")
+	fs.IntVar(&f.parallel, "parallel", 1, "Maximum number of templates to run concurrently")
+	fs.DurationVar(&f.timeout, "timeout", 0, "Default time limit for each template, such as 10m (0 means no limit)")
+	fs.StringVar(&f.collisionPolicy, "collision-policy", "", "What happens when two templates write the same file: error, warn, last-wins or first-wins (defaults to the compose file's collision-policy, or warn)")
+	fs.BoolVar(&f.frozen, "frozen", false, "Fail when the lock file is missing or out of date")
+	fs.BoolVar(&f.offline, "offline", false, "Only use template sources from the cache; fail instead of fetching")
+	fs.StringVar(&f.cacheDir, "cache-dir", "", "Directory for cached template sources (defaults to $"+cache.DirEnvVar+", or boilerplate-compose in the user cache directory)")
+	fs.Var(&f.exclude, "exclude", "Skip templates matching this name or glob, may be repeated")
+	fs.BoolVar(&f.noDeps, "no-deps", false, "Do not add the templates that selected templates depend on")
+	fs.Var(&f.profiles, "profile", "Enable templates with this template profile, may be repeated (defaults to $"+config.ProfilesEnvVar+"). Does not load any .env file; see -env-profile")
+}
+
+// orchestrator validates the flags, loads the compose file and returns an
+// orchestrator for the selected templates. opts provides the options that
+// are not set by flags.
+func (f *runFlags) orchestrator(selection []string, opts processor.Options) (*processor.Orchestrator, error) {
+	if f.parallel < 1 {
+		return nil, fmt.Errorf("-parallel must be at least 1, got %d", f.parallel)
+	}
+	if f.timeout < 0 {
+		return nil, fmt.Errorf("-timeout must not be negative, got %v", f.timeout)
+	}
+
+	templateEngine, err := executor.ParseEngine(f.engine)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy config.CollisionPolicy
+	if f.collisionPolicy != "" {
+		if policy, err = config.ParseCollisionPolicy(f.collisionPolicy); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg, configPath, err := f.load.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyLock(cfg, configPath, f.frozen); err != nil {
+		return nil, err
+	}
+
+	// The command line overrides the compose file
+	if policy == "" {
+		policy = cfg.CollisionPolicy
+	}
+
+	templateProcessor := processor.NewTemplateProcessor(cfg, configPath)
+	if err := templateProcessor.Select(selection, f.exclude); err != nil {
+		return nil, err
+	}
+	templateProcessor.SetProfiles(activeProfiles(f.profiles))
+	templateProcessor.SetNoDeps(f.noDeps)
+	templateExecutor, err := executor.New(templateEngine, f.boilerplatePath, f.verbose)
+	if err != nil {
+		return nil, err
+	}
+	// Git templates are fetched into the cache when they run, at their
+	// locked commit when there is a lock file
+	templateExecutor = cache.NewExecutor(cache.New(f.cacheDir, f.offline), templateExecutor)
+
+	opts.Parallel = f.parallel
+	opts.Timeout = f.timeout
+	opts.CollisionPolicy = policy
+	return processor.NewOrchestratorWithOptions(templateProcessor, templateExecutor, opts), nil
+}
+
+// dryRunFlag is the -dry-run flag. Given alone it prints the boilerplate
+// commands; -dry-run=diff shows the changes to the output folders instead.
+type dryRunFlag string
+
+const (
+	dryRunCommands dryRunFlag = "true"
+	dryRunDiff     dryRunFlag = "diff"
+)
+
+func (f *dryRunFlag) String() string {
+	return string(*f)
+}
+
+func (f *dryRunFlag) Set(value string) error {
+	switch value {
+	case "true", "diff":
+		*f = dryRunFlag(value)
+	case "false":
+		*f = ""
+	default:
+		return fmt.Errorf("expected true, false or diff")
+	}
+	return nil
+}
+
+// IsBoolFlag lets -dry-run be given without a value.
+func (f *dryRunFlag) IsBoolFlag() bool {
+	return true
+}
+
+// activeProfiles returns the profiles given with -profile, or those listed in
+// the profiles environment variable when there are none
+func activeProfiles(flags []string) []string {
+	if len(flags) > 0 {
+		return config.ParseProfiles(strings.Join(flags, ","))
+	}
+	return config.ParseProfiles(os.Getenv(config.ProfilesEnvVar))
+}
+
+// interruptContext returns a context that is cancelled on the first SIGINT
+// or SIGTERM, so running templates are stopped and no new ones start. A
+// second signal gets the default behaviour and exits immediately.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			signal.Stop(signals)
+			log.Printf("Received %v, stopping running templates (send again to exit immediately)", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"boilerplate-compose/config"
+	"boilerplate-compose/processor"
+)
+
+// runValidate implements the validate command, which loads the compose file
+// and works out the execution order without running any template. Every
+// problem found is printed with its position in the file, and templates
+// with overlapping output folders are reported as warnings. Templates are
+// checked whatever their profiles.
+func runValidate(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	var load loadFlags
+	load.register(fs)
+	schema := fs.Bool("schema", false, "Print the JSON Schema of the compose file format and exit")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	if *schema {
+		_, err := stdout.Write(config.JSONSchema())
+		return err
+	}
+
+	cfg, configPath, err := load.loadConfig()
+	if err != nil {
+		var diagnostics config.Diagnostics
+		if errors.As(err, &diagnostics) {
+			for _, diagnostic := range diagnostics {
+				fmt.Fprintln(stdout, diagnostic)
+			}
+			return fmt.Errorf("%d problem(s) found", len(diagnostics))
+		}
+		return err
+	}
+
+	templateProcessor := processor.NewTemplateProcessor(cfg, configPath)
+	templateProcessor.SetProfiles([]string{config.AllProfiles})
+
+	jobs, err := templateProcessor.ExecutionPlan()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "%s is valid (%d templates)\n", configPath, len(jobs))
+	for _, overlap := range processor.FindOverlaps(jobs) {
+		fmt.Fprintf(stdout, "warning: %s\n", overlap)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CollisionPolicy decides what happens when a template writes a file that
+// another template already wrote in the same run.
+type CollisionPolicy string
+
+const (
+	// CollisionError keeps the file of the first template and fails the
+	// template that wrote it again
+	CollisionError CollisionPolicy = "error"
+	// CollisionWarn keeps the file of the last template and logs a warning
+	CollisionWarn CollisionPolicy = "warn"
+	// CollisionLastWins keeps the file of the last template
+	CollisionLastWins CollisionPolicy = "last-wins"
+	// CollisionFirstWins keeps the file of the first template
+	CollisionFirstWins CollisionPolicy = "first-wins"
+)
+
+// DefaultCollisionPolicy is used when neither the compose file nor the
+// command line sets a policy.
+const DefaultCollisionPolicy = CollisionWarn
+
+// CollisionPolicies are the valid collision policies.
+var CollisionPolicies = []string{
+	string(CollisionError),
+	string(CollisionWarn),
+	string(CollisionLastWins),
+	string(CollisionFirstWins),
+}
+
+// ParseCollisionPolicy validates a collision policy name
+func ParseCollisionPolicy(name string) (CollisionPolicy, error) {
+	switch p := CollisionPolicy(name); p {
+	case CollisionError, CollisionWarn, CollisionLastWins, CollisionFirstWins:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid collision policy %q (expected one of %s)", name, strings.Join(CollisionPolicies, ", "))
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Diagnostic is a problem found in a compose file, with the position of the
+// value it concerns when that is known.
+type Diagnostic struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func newDiagnostic(file string, node *yaml.Node, format string, args ...interface{}) Diagnostic {
+	d := Diagnostic{File: file, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		d.Line, d.Column = node.Line, node.Column
+	}
+	return d
+}
+
+// String formats the diagnostic as file:line:column: message.
+func (d Diagnostic) String() string {
+	switch {
+	case d.File == "":
+		return d.Message
+	case d.Line == 0:
+		return fmt.Sprintf("%s: %s", d.File, d.Message)
+	default:
+		return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, d.Message)
+	}
+}
+
+// Diagnostics is every problem found while loading a compose file. The
+// loader returns it, wrapped, as its error when the file is invalid.
+type Diagnostics []Diagnostic
+
+func (d Diagnostics) Error() string {
+	if len(d) == 1 {
+		return d[0].String()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d problems found:", len(d))
+	for _, diagnostic := range d {
+		fmt.Fprintf(&b, "\n  %s", diagnostic)
+	}
+	return b.String()
+}
+
+// add appends the diagnostic unless an identical one is already present, as
+// happens when a file is loaded more than once.
+func (d *Diagnostics) add(diagnostic Diagnostic) {
+	for _, existing := range *d {
+		if existing == diagnostic {
+			return
+		}
+	}
+	*d = append(*d, diagnostic)
+}
@@ -3,24 +3,59 @@ package config
 import (
 	"fmt"
 	"os"
-	"regexp"
+	"path/filepath"
 	"strings"
 
 	"github.com/joho/godotenv"
 )
 
+// SourceShell is the source recorded for variables from the system environment
+const SourceShell = "shell"
+
+// Precedence decides which source wins when a variable is set both in the
+// shell environment and in an env file
+type Precedence string
+
+const (
+	// PrecedenceShell keeps shell variables over env file values, like Docker Compose
+	PrecedenceShell Precedence = "shell"
+	// PrecedenceFile lets env file values override shell variables
+	PrecedenceFile Precedence = "file"
+)
+
+// ParsePrecedence validates a precedence name
+func ParsePrecedence(name string) (Precedence, error) {
+	switch p := Precedence(name); p {
+	case PrecedenceShell, PrecedenceFile:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid env precedence %q (expected %q or %q)", name, PrecedenceShell, PrecedenceFile)
+}
+
 // EnvironmentManager handles environment variables and .env file parsing
 type EnvironmentManager struct {
 	envVars map[string]string
+	// sources records where each variable came from: SourceShell, the path
+	// of an env file, or empty for variables set in code
+	sources    map[string]string
+	precedence Precedence
 }
 
 // NewEnvironmentManager creates a new environment manager
 func NewEnvironmentManager() *EnvironmentManager {
 	return &EnvironmentManager{
-		envVars: make(map[string]string),
+		envVars:    make(map[string]string),
+		sources:    make(map[string]string),
+		precedence: PrecedenceShell,
 	}
 }
 
+// SetPrecedence sets whether env files may override shell variables. It
+// affects files loaded afterwards.
+func (em *EnvironmentManager) SetPrecedence(precedence Precedence) {
+	em.precedence = precedence
+}
+
 // LoadEnvironmentFromFile loads variables from a .env file
 func (em *EnvironmentManager) LoadEnvironmentFromFile(envFilePath string) error {
 	if envFilePath == "" {
@@ -33,40 +68,64 @@ func (em *EnvironmentManager) LoadEnvironmentFromFile(envFilePath string) error
 	}
 
 	for key, value := range fileEnv {
+		if em.precedence == PrecedenceShell && em.sources[key] == SourceShell {
+			continue
+		}
 		em.envVars[key] = value
+		em.sources[key] = envFilePath
 	}
 
 	return nil
 }
 
+// LoadEnvironmentFiles loads several .env files in order, so values from
+// later files override earlier ones
+func (em *EnvironmentManager) LoadEnvironmentFiles(envFilePaths []string) error {
+	for _, path := range envFilePaths {
+		if err := em.LoadEnvironmentFromFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DefaultEnvFiles returns the .env files found in dir, in the order they
+// should be applied: .env, .env.local, and with a profile also
+// .env.<profile> and .env.<profile>.local
+func DefaultEnvFiles(dir, profile string) []string {
+	candidates := []string{".env", ".env.local"}
+	if profile != "" {
+		candidates = append(candidates, ".env."+profile, ".env."+profile+".local")
+	}
+
+	var files []string
+	for _, candidate := range candidates {
+		path := filepath.Join(dir, candidate)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
 // LoadSystemEnvironment loads variables from the system environment
 func (em *EnvironmentManager) LoadSystemEnvironment() {
 	for _, env := range os.Environ() {
 		parts := strings.SplitN(env, "=", 2)
 		if len(parts) == 2 {
 			em.envVars[parts[0]] = parts[1]
+			em.sources[parts[0]] = SourceShell
 		}
 	}
 }
 
-
-// InterpolateString performs variable interpolation on a string using ${VAR} syntax
+// InterpolateString performs variable interpolation on a string using the
+// syntax described on Interpolate. References that cannot be resolved,
+// including required variables without a value, are left unchanged.
 func (em *EnvironmentManager) InterpolateString(input string) string {
-	// Regex to match ${VAR} patterns
-	re := regexp.MustCompile(`\$\{([^}]+)\}`)
-	
-	return re.ReplaceAllStringFunc(input, func(match string) string {
-		// Extract variable name from ${VAR}
-		varName := match[2 : len(match)-1] // Remove ${ and }
-		
-		// Look up the variable value
-		if value, exists := em.envVars[varName]; exists {
-			return value
-		}
-		
-		// If variable not found, return the original match
-		return match
-	})
+	ip := &interpolator{lookup: em.GetVariable, lenient: true}
+	result, _ := ip.expand(input)
+	return result
 }
 
 // InterpolateMapValues performs variable interpolation on all string values in a map
@@ -87,6 +146,16 @@ func (em *EnvironmentManager) GetVariable(name string) (string, bool) {
 // SetVariable sets an environment variable
 func (em *EnvironmentManager) SetVariable(name, value string) {
 	em.envVars[name] = value
+	delete(em.sources, name)
+}
+
+// GetSource returns where a variable's value came from: SourceShell, the
+// path of the env file, or an empty string for variables set in code
+func (em *EnvironmentManager) GetSource(name string) (string, bool) {
+	if _, exists := em.envVars[name]; !exists {
+		return "", false
+	}
+	return em.sources[name], true
 }
 
 // GetAllVariables returns a copy of all environment variables
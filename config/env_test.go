@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -211,4 +212,136 @@ func TestGetAllVariables(t *testing.T) {
 	if originalValue != "value1" {
 		t.Error("GetAllVariables should return a copy, not the original map")
 	}
-}
\ No newline at end of file
+}
+func TestLoadEnvironmentFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	base := filepath.Join(tempDir, "base.env")
+	override := filepath.Join(tempDir, "override.env")
+	if err := os.WriteFile(base, []byte("TAG=v1\nAUTHOR=platform\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	if err := os.WriteFile(override, []byte("TAG=v2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	em := NewEnvironmentManager()
+	if err := em.LoadEnvironmentFiles([]string{base, override}); err != nil {
+		t.Fatalf("LoadEnvironmentFiles failed: %v", err)
+	}
+
+	if value, _ := em.GetVariable("TAG"); value != "v2" {
+		t.Errorf("Expected later file to win, got TAG=%q", value)
+	}
+	if value, _ := em.GetVariable("AUTHOR"); value != "platform" {
+		t.Errorf("Expected AUTHOR from first file, got %q", value)
+	}
+
+	if err := em.LoadEnvironmentFiles([]string{filepath.Join(tempDir, "missing.env")}); err == nil {
+		t.Error("Expected error for missing env file")
+	}
+}
+
+func TestDefaultEnvFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{".env", ".env.local", ".env.staging", ".env.production.local"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("A=1\n"), 0644); err != nil {
+			t.Fatalf("Failed to write env file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		profile  string
+		expected []string
+	}{
+		{"", []string{".env", ".env.local"}},
+		{"staging", []string{".env", ".env.local", ".env.staging"}},
+		{"production", []string{".env", ".env.local", ".env.production.local"}},
+	}
+
+	for _, test := range tests {
+		var expected []string
+		for _, name := range test.expected {
+			expected = append(expected, filepath.Join(tempDir, name))
+		}
+
+		got := DefaultEnvFiles(tempDir, test.profile)
+		if strings.Join(got, ",") != strings.Join(expected, ",") {
+			t.Errorf("DefaultEnvFiles(%q): expected %v, got %v", test.profile, expected, got)
+		}
+	}
+}
+
+func TestEnvironmentPrecedence(t *testing.T) {
+	testKey := "TEST_ENV_PRECEDENCE_VAR"
+	t.Setenv(testKey, "from-shell")
+
+	envFile := filepath.Join(t.TempDir(), ".env")
+	content := testKey + "=from-file\nFILE_ONLY_VAR=file-value\n"
+	if err := os.WriteFile(envFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	tests := []struct {
+		precedence     Precedence
+		expectedValue  string
+		expectedSource string
+	}{
+		{PrecedenceShell, "from-shell", SourceShell},
+		{PrecedenceFile, "from-file", envFile},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.precedence), func(t *testing.T) {
+			em := NewEnvironmentManager()
+			em.SetPrecedence(test.precedence)
+			em.LoadSystemEnvironment()
+			if err := em.LoadEnvironmentFromFile(envFile); err != nil {
+				t.Fatalf("LoadEnvironmentFromFile failed: %v", err)
+			}
+
+			if value, _ := em.GetVariable(testKey); value != test.expectedValue {
+				t.Errorf("Expected %q, got %q", test.expectedValue, value)
+			}
+			if source, _ := em.GetSource(testKey); source != test.expectedSource {
+				t.Errorf("Expected source %q, got %q", test.expectedSource, source)
+			}
+			if source, _ := em.GetSource("FILE_ONLY_VAR"); source != envFile {
+				t.Errorf("Expected file-only variable source %q, got %q", envFile, source)
+			}
+		})
+	}
+
+	t.Run("default is shell", func(t *testing.T) {
+		em := NewEnvironmentManager()
+		em.LoadSystemEnvironment()
+		if err := em.LoadEnvironmentFromFile(envFile); err != nil {
+			t.Fatalf("LoadEnvironmentFromFile failed: %v", err)
+		}
+		if value, _ := em.GetVariable(testKey); value != "from-shell" {
+			t.Errorf("Expected shell value to win by default, got %q", value)
+		}
+	})
+}
+
+func TestGetSource(t *testing.T) {
+	em := NewEnvironmentManager()
+	em.SetVariable("IN_CODE", "value")
+
+	if source, exists := em.GetSource("IN_CODE"); !exists || source != "" {
+		t.Errorf("Expected empty source for variable set in code, got %q, %v", source, exists)
+	}
+	if _, exists := em.GetSource("MISSING"); exists {
+		t.Error("Expected missing variable to have no source")
+	}
+}
+
+func TestParsePrecedence(t *testing.T) {
+	for _, name := range []string{"shell", "file"} {
+		if p, err := ParsePrecedence(name); err != nil || string(p) != name {
+			t.Errorf("ParsePrecedence(%q) = %q, %v", name, p, err)
+		}
+	}
+	if _, err := ParsePrecedence("env"); err == nil {
+		t.Error("Expected error for invalid precedence")
+	}
+}
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RequiredVariableError is returned when a ${VAR:?message} or ${VAR?message}
+// reference names a variable without a (non-empty) value.
+type RequiredVariableError struct {
+	Name    string
+	Message string
+}
+
+func (e *RequiredVariableError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("required variable %s is missing a value", e.Name)
+	}
+	return fmt.Sprintf("required variable %s is missing a value: %s", e.Name, e.Message)
+}
+
+// Interpolate replaces variable references in input using Docker Compose
+// syntax:
+//
+//	$VAR, ${VAR}         value of VAR
+//	${VAR:-default}      default if VAR is unset or empty
+//	${VAR-default}       default if VAR is unset
+//	${VAR:?message}      error if VAR is unset or empty
+//	${VAR?message}       error if VAR is unset
+//	${VAR:+replacement}  replacement if VAR is set and non-empty, otherwise empty
+//	${VAR+replacement}   replacement if VAR is set, otherwise empty
+//	$$                   a literal $
+//
+// Defaults, messages and replacements may contain further references.
+// References to unset variables without a modifier are left unchanged.
+func (em *EnvironmentManager) Interpolate(input string) (string, error) {
+	ip := &interpolator{lookup: em.GetVariable}
+	return ip.expand(input)
+}
+
+type interpolator struct {
+	lookup func(name string) (string, bool)
+	// lenient leaves references that would fail in place instead of
+	// returning an error.
+	lenient bool
+	// unresolved collects the names of variables whose references were
+	// left unchanged because they are not set.
+	unresolved []string
+}
+
+func (ip *interpolator) expand(input string) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(input); {
+		if input[i] != '$' || i+1 == len(input) {
+			b.WriteByte(input[i])
+			i++
+			continue
+		}
+
+		next := input[i+1]
+		switch {
+		case next == '$':
+			b.WriteByte('$')
+			i += 2
+
+		case next == '{':
+			end := closingBrace(input, i+1)
+			if end < 0 {
+				// Unterminated reference, keep the rest as-is
+				b.WriteString(input[i:])
+				return b.String(), nil
+			}
+
+			value, err := ip.expandBraced(input[i+2:end], input[i:end+1])
+			if err != nil {
+				if !ip.lenient {
+					return "", err
+				}
+				value = input[i : end+1]
+			}
+			b.WriteString(value)
+			i = end + 1
+
+		case isNameStart(next):
+			j := i + 1
+			for j < len(input) && isNameChar(input[j]) {
+				j++
+			}
+			if value, ok := ip.lookup(input[i+1 : j]); ok {
+				b.WriteString(value)
+			} else {
+				b.WriteString(input[i:j])
+				ip.unresolved = append(ip.unresolved, input[i+1:j])
+			}
+			i = j
+
+		default:
+			b.WriteByte('$')
+			i++
+		}
+	}
+
+	return b.String(), nil
+}
+
+// expandBraced expands the expression between ${ and }. original is the full
+// reference, returned unchanged when the expression cannot be resolved.
+func (ip *interpolator) expandBraced(expr, original string) (string, error) {
+	n := 0
+	for n < len(expr) && (n == 0 && isNameStart(expr[n]) || n > 0 && isNameChar(expr[n])) {
+		n++
+	}
+	if n == 0 {
+		return original, nil
+	}
+
+	name, rest := expr[:n], expr[n:]
+	value, set := ip.lookup(name)
+
+	if rest == "" {
+		if !set {
+			ip.unresolved = append(ip.unresolved, name)
+			return original, nil
+		}
+		return value, nil
+	}
+
+	op, arg := rest[:1], rest[1:]
+	if op == ":" && len(rest) > 1 {
+		op, arg = rest[:2], rest[2:]
+	}
+
+	switch op {
+	case ":-":
+		if set && value != "" {
+			return value, nil
+		}
+		return ip.expand(arg)
+	case "-":
+		if set {
+			return value, nil
+		}
+		return ip.expand(arg)
+	case ":?":
+		if set && value != "" {
+			return value, nil
+		}
+		return "", ip.requiredError(name, arg)
+	case "?":
+		if set {
+			return value, nil
+		}
+		return "", ip.requiredError(name, arg)
+	case ":+":
+		if set && value != "" {
+			return ip.expand(arg)
+		}
+		return "", nil
+	case "+":
+		if set {
+			return ip.expand(arg)
+		}
+		return "", nil
+	}
+
+	// Not a supported modifier, leave the reference alone
+	return original, nil
+}
+
+func (ip *interpolator) requiredError(name, message string) error {
+	expanded, err := ip.expand(message)
+	if err != nil {
+		return err
+	}
+	return &RequiredVariableError{Name: name, Message: expanded}
+}
+
+// closingBrace returns the index of the } that closes the ${ whose { is at
+// open, accounting for nested references, or -1 if there is none.
+func closingBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '$':
+			i++
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '{':
+			depth++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || c >= '0' && c <= '9'
+}
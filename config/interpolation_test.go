@@ -0,0 +1,122 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	em := NewEnvironmentManager()
+	em.SetVariable("TAG", "v1.5")
+	em.SetVariable("PROJECT", "my-project")
+	em.SetVariable("EMPTY", "")
+	em.SetVariable("FALLBACK", "fallback")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"$TAG", "v1.5"},
+		{"$TAG-$PROJECT", "v1.5-my-project"},
+		{"${TAG}", "v1.5"},
+		{"$UNSET", "$UNSET"},
+		{"${UNSET}", "${UNSET}"},
+		{"${UNSET:-localhost}", "localhost"},
+		{"${EMPTY:-localhost}", "localhost"},
+		{"${TAG:-localhost}", "v1.5"},
+		{"${UNSET-localhost}", "localhost"},
+		{"${EMPTY-localhost}", ""},
+		{"${TAG:+set}", "set"},
+		{"${EMPTY:+set}", ""},
+		{"${UNSET:+set}", ""},
+		{"${EMPTY+set}", "set"},
+		{"${UNSET+set}", ""},
+		{"${UNSET:-${FALLBACK}}", "fallback"},
+		{"${UNSET:-${ALSO_UNSET:-deep}}", "deep"},
+		{"${UNSET:-prefix-${TAG}-suffix}", "prefix-v1.5-suffix"},
+		{"${TAG:-${MISSING:?not evaluated}}", "v1.5"},
+		{"${UNSET:-a:b}", "a:b"},
+		{"$$TAG", "$TAG"},
+		{"$${TAG}", "${TAG}"},
+		{"cost: $5", "cost: $5"},
+		{"trailing $", "trailing $"},
+		{"${unterminated", "${unterminated"},
+		{"${invalid.name}", "${invalid.name}"},
+	}
+
+	for _, test := range tests {
+		result, err := em.Interpolate(test.input)
+		if err != nil {
+			t.Errorf("Interpolate(%q): unexpected error: %v", test.input, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("Interpolate(%q): expected %q, got %q", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestInterpolateRequired(t *testing.T) {
+	em := NewEnvironmentManager()
+	em.SetVariable("EMPTY", "")
+	em.SetVariable("NAME", "db")
+
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"${UNSET:?must be set}", "required variable UNSET is missing a value: must be set"},
+		{"${EMPTY:?must not be empty}", "required variable EMPTY is missing a value: must not be empty"},
+		{"${UNSET?}", "required variable UNSET is missing a value"},
+		{"${UNSET:?${NAME} host missing}", "required variable UNSET is missing a value: db host missing"},
+		{"${UNSET:-${NESTED:?nested}}", "required variable NESTED is missing a value: nested"},
+	}
+
+	for _, test := range tests {
+		_, err := em.Interpolate(test.input)
+		var requiredErr *RequiredVariableError
+		if !errors.As(err, &requiredErr) {
+			t.Errorf("Interpolate(%q): expected RequiredVariableError, got %v", test.input, err)
+			continue
+		}
+		if err.Error() != test.message {
+			t.Errorf("Interpolate(%q): expected error %q, got %q", test.input, test.message, err.Error())
+		}
+	}
+
+	// An empty value satisfies the non-colon form
+	if result, err := em.Interpolate("${EMPTY?unused}"); err != nil || result != "" {
+		t.Errorf("Interpolate(${EMPTY?unused}): expected empty result, got %q, %v", result, err)
+	}
+
+	// InterpolateString leaves failing references in place
+	if result := em.InterpolateString("host=${UNSET:?must be set}"); result != "host=${UNSET:?must be set}" {
+		t.Errorf("InterpolateString: expected reference to be kept, got %q", result)
+	}
+}
+
+func TestLoadConfigRequiredVariablePosition(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "compose.yaml")
+	content := `templates:
+  web:
+    template-url: "https://example.com/web"
+    output-folder: "./web"
+    vars:
+      db_host: "${DB_HOST:?set DB_HOST}"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	_, err := LoadConfigWithEnvironment(configPath, NewEnvironmentManager())
+	if err == nil {
+		t.Fatal("Expected error for missing required variable")
+	}
+	if !strings.Contains(err.Error(), configPath+":6:16: required variable DB_HOST is missing a value: set DB_HOST") {
+		t.Errorf("Expected error with file, line and column, got: %v", err)
+	}
+}
@@ -3,55 +3,721 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
-func LoadConfig(filepath string) (*ComposeConfig, error) {
-	return LoadConfigWithEnvironment(filepath, nil)
+func LoadConfig(configPath string) (*ComposeConfig, error) {
+	return LoadConfigWithEnvironment(configPath, nil)
 }
 
-func LoadConfigWithEnvironment(filepath string, envManager *EnvironmentManager) (*ComposeConfig, error) {
-	if _, err := os.Stat(filepath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("config file not found: %s", filepath)
+func LoadConfigWithEnvironment(configPath string, envManager *EnvironmentManager) (*ComposeConfig, error) {
+	return LoadConfigWithOptions(configPath, LoadOptions{Environment: envManager})
+}
+
+// LoadOptions controls how a compose file is loaded.
+type LoadOptions struct {
+	// Environment provides the variables used for interpolation. No
+	// interpolation is performed when it is nil.
+	Environment *EnvironmentManager
+	// Strict makes any variable reference that cannot be resolved an error.
+	// A compose file can also enable this with a top-level `strict: true`.
+	Strict bool
+	// EnvFiles are loaded into Environment after the env_file entries of the
+	// compose file, so their values win.
+	EnvFiles []string
+}
+
+func LoadConfigWithOptions(configPath string, opts LoadOptions) (*ComposeConfig, error) {
+	l := &loader{envManager: opts.Environment, envFiles: opts.EnvFiles}
+
+	file, err := l.loadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	strict := opts.Strict || file.config.Strict
+
+	// A file that could not be decoded leaves templates incomplete, which
+	// would only add misleading problems to the ones already found
+	if l.decodeFailed {
+		if strict && len(l.unresolved) > 0 {
+			return nil, unresolvedVariablesError(l.unresolved)
+		}
+		return nil, fmt.Errorf("config validation failed: %w", l.diagnostics)
+	}
+
+	// Resolving extends loads the extended files, whose variable references
+	// are checked along with those of the compose file
+	extendsErr := l.resolveExtends(file)
+	if strict && len(l.unresolved) > 0 {
+		return nil, unresolvedVariablesError(l.unresolved)
+	}
+	if extendsErr != nil {
+		return nil, extendsErr
+	}
+
+	diagnostics := append(l.diagnostics, validateConfig(file)...)
+	if len(diagnostics) > 0 {
+		return nil, fmt.Errorf("config validation failed: %w", diagnostics)
+	}
+
+	return file.config, nil
+}
+
+// loader reads a compose file and, recursively, every file it includes or
+// extends from.
+type loader struct {
+	envManager *EnvironmentManager
+	// stack holds the absolute paths of the files currently being loaded and
+	// is used to detect include cycles.
+	stack []string
+	// envFiles are loaded after the env_file entries of the compose file.
+	// envLoaded is set once they have been.
+	envFiles  []string
+	envLoaded bool
+	// unresolved records every variable reference left in place because the
+	// variable is not set.
+	unresolved []UnresolvedVariable
+	// diagnostics records the problems found in every loaded file.
+	diagnostics Diagnostics
+	// decodeFailed is set when a file with problems could not be decoded.
+	decodeFailed bool
+}
+
+// UnresolvedVariable is a variable reference that could not be resolved.
+type UnresolvedVariable struct {
+	Name   string
+	File   string
+	Line   int
+	Column int
+	// Template is the template the reference appears in, if any.
+	Template string
+	// Field is the dotted path of the value within the template, or within
+	// the file for references outside templates.
+	Field string
+}
+
+func (u UnresolvedVariable) String() string {
+	location := fmt.Sprintf("field '%s'", u.Field)
+	if u.Template != "" {
+		location = fmt.Sprintf("template '%s' field '%s'", u.Template, u.Field)
 	}
+	return fmt.Sprintf("%s in %s (%s:%d:%d)", u.Name, location, u.File, u.Line, u.Column)
+}
 
-	data, err := os.ReadFile(filepath)
+func unresolvedVariablesError(unresolved []UnresolvedVariable) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d unresolved variable reference(s) in strict mode:", len(unresolved))
+	for _, u := range unresolved {
+		fmt.Fprintf(&b, "\n  - %s", u)
+	}
+	return fmt.Errorf("%s", b.String())
+}
+
+// composeFile is a loaded compose file together with the raw template
+// definitions needed to resolve extends.
+type composeFile struct {
+	config *ComposeConfig
+	// path is the path of the file as it was given to the loader.
+	path string
+	// root is the top-level mapping of the file.
+	root *yaml.Node
+	// paths maps the absolute path of this file and of every file it
+	// includes to the path it was given as, for reporting problems.
+	paths map[string]string
+	// nodes holds the YAML definition of every template, keyed by name.
+	nodes map[string]*yaml.Node
+	// extends holds the effective base of every template that extends another.
+	extends map[string]*ExtendsConfig
+}
+
+func (l *loader) loadFile(configPath string) (*composeFile, error) {
+	absPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path %s: %w", configPath, err)
+	}
+
+	for i, p := range l.stack {
+		if p == absPath {
+			cycle := append(append([]string{}, l.stack[i:]...), absPath)
+			return nil, fmt.Errorf("include cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	l.stack = append(l.stack, absPath)
+	defer func() { l.stack = l.stack[:len(l.stack)-1] }()
+
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("config file not found: %s", configPath)
+	}
+
+	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	// Perform environment variable interpolation on the raw YAML content if envManager is provided
-	if envManager != nil {
-		interpolatedData := envManager.InterpolateString(string(data))
-		data = []byte(interpolatedData)
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	// The env files of the top-level file set variables that it, and every
+	// file it includes or extends, may use
+	if l.envManager != nil && !l.envLoaded {
+		l.envLoaded = true
+		files, err := composeEnvFiles(configPath, &doc)
+		if err != nil {
+			return nil, err
+		}
+		if err := l.envManager.LoadEnvironmentFiles(append(files, l.envFiles...)); err != nil {
+			return nil, fmt.Errorf("failed to load environment file: %w", err)
+		}
+	}
+
+	// Perform environment variable interpolation on the parsed values if
+	// envManager is provided, so substituted text can never change the
+	// structure of the document
+	if l.envManager != nil {
+		if err := l.interpolateNode(configPath, &doc, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	problems := checkSchema(configPath, &doc)
+	for _, problem := range problems {
+		l.diagnostics.add(problem)
 	}
 
 	var config ComposeConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	if doc.Kind != 0 {
+		if err := doc.Decode(&config); err != nil {
+			if len(problems) == 0 {
+				return nil, fmt.Errorf("failed to parse YAML: %w", err)
+			}
+			// The problems found explain the error; keep loading what
+			// could be decoded so included files are checked as well
+			l.decodeFailed = true
+		}
 	}
 
-	if err := validateConfig(&config); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
+	file := &composeFile{
+		config:  &config,
+		path:    configPath,
+		root:    documentRoot(&doc),
+		paths:   map[string]string{absPath: configPath},
+		nodes:   templateNodes(&doc),
+		extends: make(map[string]*ExtendsConfig),
 	}
 
-	return &config, nil
+	config.sources = make(map[string]string, len(config.Templates))
+	for name, template := range config.Templates {
+		config.sources[name] = absPath
+
+		if template.Extends != nil {
+			file.extends[name] = template.Extends
+		} else if config.Extends != nil && !isFileBase(config.Extends, absPath, name) {
+			// A top-level extends applies to every template of the file
+			// that does not declare its own, except the base itself.
+			file.extends[name] = config.Extends
+		}
+	}
+
+	// Included templates come before the file's own templates
+	ownOrder := config.order
+	config.order = nil
+
+	for _, include := range config.Include {
+		if include.Path == "" {
+			return nil, fmt.Errorf("%s: include path is required", configPath)
+		}
+
+		// Include paths are resolved relative to the including file
+		includePath := include.Path
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(configPath), includePath)
+		}
+
+		included, err := l.loadFile(includePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load include '%s' from %s: %w", include.Path, configPath, err)
+		}
+
+		if err := file.merge(included); err != nil {
+			return nil, err
+		}
+	}
+	config.order = append(config.order, ownOrder...)
+
+	return file, nil
+}
+
+// interpolateNode substitutes variables in every scalar value of the tree.
+// Mapping keys are left untouched. A failing reference is reported with its
+// position in the file. path holds the keys leading to node.
+func (l *loader) interpolateNode(configPath string, node *yaml.Node, path []string) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		ip := &interpolator{lookup: l.envManager.GetVariable}
+		value, err := ip.expand(node.Value)
+		if err != nil {
+			return fmt.Errorf("%s:%d:%d: %w", configPath, node.Line, node.Column, err)
+		}
+		l.recordUnresolved(configPath, node, path, ip.unresolved)
+
+		if value != node.Value {
+			node.Value = value
+			// Let plain scalars resolve their type from the substituted
+			// value, so `non-interactive: ${CI}` still decodes as a bool
+			if node.Style&(yaml.TaggedStyle|yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+				node.Tag = ""
+			}
+		}
+
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := l.interpolateNode(configPath, node.Content[i+1], append(path, node.Content[i].Value)); err != nil {
+				return err
+			}
+		}
+
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			if err := l.interpolateNode(configPath, child, append(path, fmt.Sprintf("[%d]", i))); err != nil {
+				return err
+			}
+		}
+
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := l.interpolateNode(configPath, child, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Aliases share their anchor's node, which is interpolated in place
+	return nil
+}
+
+func (l *loader) recordUnresolved(configPath string, node *yaml.Node, path []string, names []string) {
+	template := ""
+	fieldPath := path
+	if len(path) >= 3 && path[0] == "templates" {
+		template, fieldPath = path[1], path[2:]
+	}
+	field := strings.ReplaceAll(strings.Join(fieldPath, "."), ".[", "[")
+
+	for _, name := range names {
+		u := UnresolvedVariable{
+			Name:     name,
+			File:     configPath,
+			Line:     node.Line,
+			Column:   node.Column,
+			Template: template,
+			Field:    field,
+		}
+
+		// A file loaded more than once reports each reference only once
+		duplicate := false
+		for _, existing := range l.unresolved {
+			if existing == u {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			l.unresolved = append(l.unresolved, u)
+		}
+	}
 }
 
-func validateConfig(config *ComposeConfig) error {
+// merge adds the templates of an included file. A template name may only be
+// defined once across all files; the same file reached through several
+// include paths is not considered a conflict.
+func (f *composeFile) merge(included *composeFile) error {
+	c := f.config
+	for abs, path := range included.paths {
+		if _, exists := f.paths[abs]; !exists {
+			f.paths[abs] = path
+		}
+	}
+	if c.Templates == nil {
+		c.Templates = make(map[string]Template, len(included.config.Templates))
+	}
+
+	for _, name := range included.config.TemplateNames() {
+		template := included.config.Templates[name]
+		source := included.config.sources[name]
+		if existing, exists := c.sources[name]; exists {
+			if existing == source {
+				continue
+			}
+			return fmt.Errorf("template '%s' is defined in both %s and %s", name, existing, source)
+		}
+		c.Templates[name] = template
+		c.sources[name] = source
+		c.order = append(c.order, name)
+		f.nodes[name] = included.nodes[name]
+		if ext, ok := included.extends[name]; ok {
+			f.extends[name] = ext
+		}
+	}
+
+	return nil
+}
+
+// resolveExtends replaces every template of the file with its definition
+// merged on top of the chain of templates it extends.
+func (l *loader) resolveExtends(file *composeFile) error {
+	for _, name := range file.config.TemplateNames() {
+		node, err := l.resolveTemplateNode(file, name, nil)
+		if err != nil {
+			return err
+		}
+
+		var template Template
+		if err := node.Decode(&template); err != nil {
+			return fmt.Errorf("template '%s': %w", name, err)
+		}
+		template.Extends = nil
+		file.config.Templates[name] = template
+	}
+
+	return nil
+}
+
+func (l *loader) resolveTemplateNode(file *composeFile, name string, chain []string) (*yaml.Node, error) {
+	node, ok := file.nodes[name]
+	if !ok {
+		return nil, fmt.Errorf("template '%s' not found", name)
+	}
+
+	source := file.config.sources[name]
+	link := fmt.Sprintf("%s (%s)", name, source)
+	for i, c := range chain {
+		if c == link {
+			cycle := append(append([]string{}, chain[i:]...), link)
+			return nil, fmt.Errorf("extends cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	ext, ok := file.extends[name]
+	if !ok {
+		return node, nil
+	}
+
+	if ext.Template == "" {
+		return nil, fmt.Errorf("template '%s': extends.template is required", name)
+	}
+
+	baseFile := file
+	if ext.File != "" {
+		// Extended files are resolved relative to the file defining the template
+		basePath := ext.File
+		if !filepath.IsAbs(basePath) {
+			basePath = filepath.Join(filepath.Dir(source), basePath)
+		}
+
+		var err error
+		baseFile, err = l.loadFile(basePath)
+		if err != nil {
+			return nil, fmt.Errorf("template '%s': failed to load extended file '%s': %w", name, ext.File, err)
+		}
+	}
+
+	baseNode, err := l.resolveTemplateNode(baseFile, ext.Template, append(chain, link))
+	if err != nil {
+		return nil, fmt.Errorf("template '%s': %w", name, err)
+	}
+
+	if baseNode.Kind != yaml.MappingNode || node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("template '%s': extends requires both templates to be mappings", name)
+	}
+
+	return mergeTemplateNodes(baseNode, node), nil
+}
+
+// isFileBase reports whether the named template in the given file is the
+// base template referenced by a top-level extends.
+func isFileBase(ext *ExtendsConfig, absPath, name string) bool {
+	if ext.Template != name {
+		return false
+	}
+	if ext.File == "" {
+		return true
+	}
+
+	basePath := ext.File
+	if !filepath.IsAbs(basePath) {
+		basePath = filepath.Join(filepath.Dir(absPath), basePath)
+	}
+	return filepath.Clean(basePath) == absPath
+}
+
+// templateNodes returns the definition node of every template in a document.
+func templateNodes(doc *yaml.Node) map[string]*yaml.Node {
+	nodes := make(map[string]*yaml.Node)
+
+	templates := mappingValue(documentRoot(doc), "templates")
+	if templates == nil || templates.Kind != yaml.MappingNode {
+		return nodes
+	}
+
+	for i := 0; i+1 < len(templates.Content); i += 2 {
+		nodes[templates.Content[i].Value] = templates.Content[i+1]
+	}
+
+	return nodes
+}
+
+// documentRoot returns the top-level node of a document, or nil for an
+// empty document.
+func documentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode {
+		if len(doc.Content) == 0 {
+			return nil
+		}
+		return doc.Content[0]
+	}
+	if doc.Kind == 0 {
+		return nil
+	}
+	return doc
+}
+
+// composeEnvFiles returns the env_file entries of a parsed compose file,
+// resolved relative to the compose file. They are read before interpolation.
+func composeEnvFiles(configPath string, doc *yaml.Node) ([]string, error) {
+	node := mappingValue(documentRoot(doc), "env_file")
+	if node == nil {
+		return nil, nil
+	}
+
+	var entries StringList
+	if err := node.Decode(&entries); err != nil {
+		return nil, fmt.Errorf("%s:%d:%d: invalid env_file: %w", configPath, node.Line, node.Column, err)
+	}
+
+	files := make([]string, 0, len(entries))
+	for _, file := range entries {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(configPath), file)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// mappingValue returns the value stored under key in a mapping node.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// mergeTemplateNodes returns a template definition with every field of
+// override applied on top of base. Vars are deep-merged and var-file lists
+// are concatenated; all other fields are replaced.
+func mergeTemplateNodes(base, override *yaml.Node) *yaml.Node {
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	index := make(map[string]int)
+
+	for _, source := range []*yaml.Node{base, override} {
+		for i := 0; i+1 < len(source.Content); i += 2 {
+			key, value := source.Content[i], source.Content[i+1]
+			if key.Value == "extends" {
+				continue
+			}
+
+			pos, exists := index[key.Value]
+			if !exists {
+				index[key.Value] = len(merged.Content)
+				merged.Content = append(merged.Content, key, value)
+				continue
+			}
+
+			existing := merged.Content[pos+1]
+			switch key.Value {
+			case "vars":
+				merged.Content[pos+1] = mergeMappingNodes(existing, value)
+			case "var-file":
+				merged.Content[pos+1] = concatSequenceNodes(existing, value)
+			default:
+				merged.Content[pos+1] = value
+			}
+		}
+	}
+
+	return merged
+}
+
+// mergeMappingNodes recursively merges two mapping nodes, with values from
+// override taking precedence. Non-mapping values are replaced.
+func mergeMappingNodes(base, override *yaml.Node) *yaml.Node {
+	if base.Kind != yaml.MappingNode || override.Kind != yaml.MappingNode {
+		return override
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	merged.Content = append(merged.Content, base.Content...)
+
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		key, value := override.Content[i], override.Content[i+1]
+
+		replaced := false
+		for j := 0; j+1 < len(merged.Content); j += 2 {
+			if merged.Content[j].Value == key.Value {
+				merged.Content[j+1] = mergeMappingNodes(merged.Content[j+1], value)
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged.Content = append(merged.Content, key, value)
+		}
+	}
+
+	return merged
+}
+
+// concatSequenceNodes concatenates two values that are each either a single
+// scalar or a sequence of scalars.
+func concatSequenceNodes(first, second *yaml.Node) *yaml.Node {
+	merged := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+
+	for _, node := range []*yaml.Node{first, second} {
+		switch node.Kind {
+		case yaml.SequenceNode:
+			merged.Content = append(merged.Content, node.Content...)
+		case yaml.ScalarNode:
+			if node.Tag != "!!null" {
+				merged.Content = append(merged.Content, node)
+			}
+		}
+	}
+
+	return merged
+}
+
+// validateConfig checks the loaded templates and returns every problem
+// found, positioned at the value it concerns where possible.
+func validateConfig(file *composeFile) Diagnostics {
+	config := file.config
+	var diagnostics Diagnostics
+
 	if len(config.Templates) == 0 {
-		return fmt.Errorf("no templates defined")
+		diagnostics.add(file.diagnostic("", file.lookup("", "templates"), "no templates defined"))
+		return diagnostics
 	}
 
-	for name, template := range config.Templates {
+	if err := validateRetry(config.Retry); err != nil {
+		diagnostics.add(file.diagnostic("", file.lookup("", "retry"), "%v", err))
+	}
+
+	for _, name := range config.TemplateNames() {
+		template := config.Templates[name]
+		report := func(node *yaml.Node, format string, args ...interface{}) {
+			diagnostics.add(file.diagnostic(name, node, "template '%s': %s", name, fmt.Sprintf(format, args...)))
+		}
+
 		if template.TemplateURL == "" {
-			return fmt.Errorf("template '%s': template-url is required", name)
+			report(file.lookup(name), "template-url is required")
 		}
 		if template.OutputFolder == "" {
-			return fmt.Errorf("template '%s': output-folder is required", name)
+			report(file.lookup(name), "output-folder is required")
+		}
+		for _, dep := range template.DependsOn {
+			if _, exists := config.Templates[dep]; !exists {
+				report(file.lookupItem(name, "depends-on", dep), "depends-on references unknown template '%s'", dep)
+			}
+		}
+		if err := validateProfiles(template.Profiles); err != nil {
+			report(file.lookup(name, "profiles"), "%v", err)
+		}
+		if template.Timeout < 0 {
+			report(file.lookup(name, "timeout"), "timeout must not be negative")
+		}
+		if err := validateRetry(template.Retry); err != nil {
+			report(file.lookup(name, "retry"), "%v", err)
+		}
+	}
+
+	return diagnostics
+}
+
+// lookup returns the node found by following keys from the definition of
+// the named template, or from the top of the file when template is empty.
+// When a key is missing, such as a field inherited through extends, the
+// last node found is returned.
+func (f *composeFile) lookup(template string, keys ...string) *yaml.Node {
+	node := f.root
+	if template != "" {
+		node = f.nodes[template]
+	}
+
+	for _, key := range keys {
+		value := mappingValue(node, key)
+		if value == nil {
+			break
 		}
+		node = value
 	}
+	return node
+}
 
+// lookupItem returns the item of a list field that has the given value.
+func (f *composeFile) lookupItem(template, key, value string) *yaml.Node {
+	node := f.lookup(template, key)
+	if node != nil && node.Kind == yaml.SequenceNode {
+		for _, item := range node.Content {
+			if item.Value == value {
+				return item
+			}
+		}
+	}
+	return node
+}
+
+// diagnostic returns a problem found in the named template, or in the file
+// itself when template is empty.
+func (f *composeFile) diagnostic(template string, node *yaml.Node, format string, args ...interface{}) Diagnostic {
+	path := f.path
+	if template != "" {
+		if source, ok := f.config.sources[template]; ok {
+			path = source
+			if given, ok := f.paths[source]; ok {
+				path = given
+			}
+		}
+	}
+	return newDiagnostic(path, node, format, args...)
+}
+
+func validateRetry(retry *RetryConfig) error {
+	if retry == nil {
+		return nil
+	}
+	if retry.Attempts < 1 {
+		return fmt.Errorf("retry: attempts must be at least 1")
+	}
+	if retry.Backoff < 0 {
+		return fmt.Errorf("retry: backoff must not be negative")
+	}
+	for _, pattern := range retry.OnStderrMatch {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("retry: invalid on-stderr-match pattern '%s': %w", pattern, err)
+		}
+	}
 	return nil
-}
\ No newline at end of file
+}
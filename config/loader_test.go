@@ -3,8 +3,10 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -75,6 +77,7 @@ include:
 `
 		tempFile := createTempConfigFile(t, emptyConfig)
 		defer os.Remove(tempFile)
+		writeConfigFile(t, filepath.Join(filepath.Dir(tempFile), "other.yaml"), "include: []\n")
 
 		_, err := LoadConfig(tempFile)
 		if err == nil {
@@ -133,7 +136,7 @@ func TestValidateConfig(t *testing.T) {
 			},
 		}
 
-		err := validateConfig(config)
+		err := validateConfig(&composeFile{config: config})
 		if err != nil {
 			t.Errorf("Expected no error for valid config, got: %v", err)
 		}
@@ -144,7 +147,7 @@ func TestValidateConfig(t *testing.T) {
 			Templates: map[string]Template{},
 		}
 
-		err := validateConfig(config)
+		err := validateConfig(&composeFile{config: config})
 		if err == nil {
 			t.Fatal("Expected error for empty templates")
 		}
@@ -163,7 +166,7 @@ func TestValidateConfig(t *testing.T) {
 			},
 		}
 
-		err := validateConfig(config)
+		err := validateConfig(&composeFile{config: config})
 		if err == nil {
 			t.Fatal("Expected validation error")
 		}
@@ -279,4 +282,675 @@ templates:
 			t.Errorf("Expected template URL '${VAR}/react-template', got '%s'", frontend.TemplateURL)
 		}
 	})
-}
\ No newline at end of file
+}
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+}
+
+func TestLoadConfigWithIncludes(t *testing.T) {
+	t.Run("merges templates relative to the including file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFile(t, filepath.Join(dir, "compose.yaml"), `
+include:
+  - path: shared/templates.yaml
+templates:
+  app:
+    template-url: "https://example.com/app"
+    output-folder: "./app"
+`)
+		writeConfigFile(t, filepath.Join(dir, "shared", "templates.yaml"), `
+include:
+  - nested/more.yaml
+templates:
+  ci:
+    template-url: "https://example.com/ci"
+    output-folder: "./ci"
+`)
+		writeConfigFile(t, filepath.Join(dir, "shared", "nested", "more.yaml"), `
+templates:
+  docs:
+    template-url: "https://example.com/docs"
+    output-folder: "./docs"
+`)
+
+		config, err := LoadConfig(filepath.Join(dir, "compose.yaml"))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if len(config.Templates) != 3 {
+			t.Fatalf("Expected 3 templates, got %d", len(config.Templates))
+		}
+		if config.Templates["docs"].TemplateURL != "https://example.com/docs" {
+			t.Errorf("Expected docs template from nested include, got %+v", config.Templates["docs"])
+		}
+		if got := config.SourceFile("ci"); got != filepath.Join(dir, "shared", "templates.yaml") {
+			t.Errorf("Expected ci source to be the shared file, got %q", got)
+		}
+	})
+
+	t.Run("interpolates included files", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFile(t, filepath.Join(dir, "compose.yaml"), `
+include:
+  - path: shared.yaml
+`)
+		writeConfigFile(t, filepath.Join(dir, "shared.yaml"), `
+templates:
+  ci:
+    template-url: "${REPO}/ci"
+    output-folder: "./ci"
+`)
+
+		envManager := NewEnvironmentManager()
+		envManager.SetVariable("REPO", "https://github.com/platform")
+
+		config, err := LoadConfigWithEnvironment(filepath.Join(dir, "compose.yaml"), envManager)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if config.Templates["ci"].TemplateURL != "https://github.com/platform/ci" {
+			t.Errorf("Expected interpolated template URL, got '%s'", config.Templates["ci"].TemplateURL)
+		}
+	})
+
+	t.Run("name collision names both files", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFile(t, filepath.Join(dir, "compose.yaml"), `
+include:
+  - path: shared.yaml
+templates:
+  ci:
+    template-url: "https://example.com/local-ci"
+    output-folder: "./ci"
+`)
+		writeConfigFile(t, filepath.Join(dir, "shared.yaml"), `
+templates:
+  ci:
+    template-url: "https://example.com/ci"
+    output-folder: "./ci"
+`)
+
+		_, err := LoadConfig(filepath.Join(dir, "compose.yaml"))
+		if err == nil {
+			t.Fatal("Expected error for duplicate template name")
+		}
+		for _, want := range []string{"template 'ci'", "compose.yaml", "shared.yaml"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected error to contain %q, got: %v", want, err)
+			}
+		}
+	})
+
+	t.Run("same file included twice is not a collision", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFile(t, filepath.Join(dir, "compose.yaml"), `
+include:
+  - a.yaml
+  - b.yaml
+`)
+		writeConfigFile(t, filepath.Join(dir, "a.yaml"), "include:\n  - common.yaml\n")
+		writeConfigFile(t, filepath.Join(dir, "b.yaml"), "include:\n  - common.yaml\n")
+		writeConfigFile(t, filepath.Join(dir, "common.yaml"), `
+templates:
+  common:
+    template-url: "https://example.com/common"
+    output-folder: "./common"
+`)
+
+		config, err := LoadConfig(filepath.Join(dir, "compose.yaml"))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(config.Templates) != 1 {
+			t.Errorf("Expected 1 template, got %d", len(config.Templates))
+		}
+	})
+
+	t.Run("include cycle", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFile(t, filepath.Join(dir, "a.yaml"), "include:\n  - b.yaml\n")
+		writeConfigFile(t, filepath.Join(dir, "b.yaml"), "include:\n  - a.yaml\n")
+
+		_, err := LoadConfig(filepath.Join(dir, "a.yaml"))
+		if err == nil {
+			t.Fatal("Expected error for include cycle")
+		}
+		if !strings.Contains(err.Error(), "include cycle detected") {
+			t.Errorf("Expected 'include cycle detected' error, got: %v", err)
+		}
+	})
+
+	t.Run("missing include", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFile(t, filepath.Join(dir, "compose.yaml"), "include:\n  - missing.yaml\n")
+
+		_, err := LoadConfig(filepath.Join(dir, "compose.yaml"))
+		if err == nil {
+			t.Fatal("Expected error for missing include")
+		}
+		if !strings.Contains(err.Error(), "config file not found") {
+			t.Errorf("Expected 'config file not found' error, got: %v", err)
+		}
+	})
+}
+
+func TestLoadConfigWithExtends(t *testing.T) {
+	t.Run("inherits and overrides fields from a template in the same file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFile(t, filepath.Join(dir, "compose.yaml"), `
+templates:
+  base:
+    template-url: "https://example.com/base"
+    output-folder: "./base"
+    non-interactive: true
+    missing-key-action: "error"
+    vars:
+      author: "platform"
+      license: "MIT"
+    var-file: "common.yaml"
+  app:
+    extends: base
+    output-folder: "./app"
+    non-interactive: false
+    vars:
+      license: "Apache-2.0"
+      name: "app"
+    var-file:
+      - "app.yaml"
+`)
+
+		config, err := LoadConfig(filepath.Join(dir, "compose.yaml"))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		app := config.Templates["app"]
+		if app.TemplateURL != "https://example.com/base" {
+			t.Errorf("Expected inherited template URL, got '%s'", app.TemplateURL)
+		}
+		if app.OutputFolder != "./app" {
+			t.Errorf("Expected overridden output folder, got '%s'", app.OutputFolder)
+		}
+		if app.NonInteractive {
+			t.Error("Expected non-interactive to be overridden to false")
+		}
+		if app.MissingKeyAction != "error" {
+			t.Errorf("Expected inherited missing-key-action, got '%s'", app.MissingKeyAction)
+		}
+
+		expectedVars := map[string]string{"author": "platform", "license": "Apache-2.0", "name": "app"}
+		if !reflect.DeepEqual(app.Vars, expectedVars) {
+			t.Errorf("Expected vars %v, got %v", expectedVars, app.Vars)
+		}
+
+		expectedVarFiles := []interface{}{"common.yaml", "app.yaml"}
+		if !reflect.DeepEqual(app.VarFile, expectedVarFiles) {
+			t.Errorf("Expected var-file %v, got %v", expectedVarFiles, app.VarFile)
+		}
+
+		if app.Extends != nil {
+			t.Error("Expected extends to be cleared after resolution")
+		}
+	})
+
+	t.Run("chained extends from another file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFile(t, filepath.Join(dir, "compose.yaml"), `
+templates:
+  app:
+    extends:
+      file: bases/service.yaml
+      template: service
+    output-folder: "./app"
+`)
+		writeConfigFile(t, filepath.Join(dir, "bases", "service.yaml"), `
+templates:
+  service:
+    extends:
+      file: root.yaml
+      template: root
+    vars:
+      kind: "service"
+`)
+		writeConfigFile(t, filepath.Join(dir, "bases", "root.yaml"), `
+templates:
+  root:
+    template-url: "https://example.com/root"
+    no-hooks: true
+    vars:
+      kind: "root"
+      owner: "platform"
+`)
+
+		config, err := LoadConfig(filepath.Join(dir, "compose.yaml"))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if len(config.Templates) != 1 {
+			t.Errorf("Expected base templates from other files not to be added, got %d templates", len(config.Templates))
+		}
+
+		app := config.Templates["app"]
+		if app.TemplateURL != "https://example.com/root" || !app.NoHooks {
+			t.Errorf("Expected fields inherited through the chain, got %+v", app)
+		}
+		if app.Vars["kind"] != "service" || app.Vars["owner"] != "platform" {
+			t.Errorf("Expected merged vars, got %v", app.Vars)
+		}
+	})
+
+	t.Run("top-level extends applies to every template but the base", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFile(t, filepath.Join(dir, "compose.yaml"), `
+extends:
+  template: base
+templates:
+  base:
+    template-url: "https://example.com/base"
+    output-folder: "./base"
+    non-interactive: true
+  app:
+    output-folder: "./app"
+`)
+
+		config, err := LoadConfig(filepath.Join(dir, "compose.yaml"))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		app := config.Templates["app"]
+		if app.TemplateURL != "https://example.com/base" || !app.NonInteractive {
+			t.Errorf("Expected app to inherit from base, got %+v", app)
+		}
+	})
+
+	t.Run("extends cycle", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFile(t, filepath.Join(dir, "compose.yaml"), `
+templates:
+  a:
+    extends: b
+    template-url: "https://example.com/a"
+    output-folder: "./a"
+  b:
+    extends: a
+    template-url: "https://example.com/b"
+    output-folder: "./b"
+`)
+
+		_, err := LoadConfig(filepath.Join(dir, "compose.yaml"))
+		if err == nil {
+			t.Fatal("Expected error for extends cycle")
+		}
+		if !strings.Contains(err.Error(), "extends cycle detected") {
+			t.Errorf("Expected 'extends cycle detected' error, got: %v", err)
+		}
+	})
+
+	t.Run("unknown base template", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFile(t, filepath.Join(dir, "compose.yaml"), `
+templates:
+  app:
+    extends: missing
+    template-url: "https://example.com/app"
+    output-folder: "./app"
+`)
+
+		_, err := LoadConfig(filepath.Join(dir, "compose.yaml"))
+		if err == nil {
+			t.Fatal("Expected error for unknown base template")
+		}
+		if !strings.Contains(err.Error(), "template 'missing' not found") {
+			t.Errorf("Expected 'not found' error, got: %v", err)
+		}
+	})
+}
+
+func TestLoadConfigTemplateOrder(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, "compose.yaml"), `
+include:
+  - shared.yaml
+templates:
+  zeta:
+    template-url: "https://example.com/zeta"
+    output-folder: "./zeta"
+  alpha:
+    template-url: "https://example.com/alpha"
+    output-folder: "./alpha"
+`)
+	writeConfigFile(t, filepath.Join(dir, "shared.yaml"), `
+templates:
+  shared-b:
+    template-url: "https://example.com/b"
+    output-folder: "./b"
+  shared-a:
+    template-url: "https://example.com/a"
+    output-folder: "./a"
+`)
+
+	for i := 0; i < 5; i++ {
+		config, err := LoadConfig(filepath.Join(dir, "compose.yaml"))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		expected := []string{"shared-b", "shared-a", "zeta", "alpha"}
+		if !reflect.DeepEqual(config.TemplateNames(), expected) {
+			t.Fatalf("Expected %v, got %v", expected, config.TemplateNames())
+		}
+	}
+}
+
+func TestLoadConfigDependsOn(t *testing.T) {
+	t.Run("parses depends-on", func(t *testing.T) {
+		tempFile := createTempConfigFile(t, `
+templates:
+  scaffold:
+    template-url: "https://example.com/scaffold"
+    output-folder: "./app"
+  ci:
+    template-url: "https://example.com/ci"
+    output-folder: "./app/ci"
+    depends-on:
+      - scaffold
+`)
+
+		config, err := LoadConfig(tempFile)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !reflect.DeepEqual(config.Templates["ci"].DependsOn, []string{"scaffold"}) {
+			t.Errorf("Expected depends-on [scaffold], got %v", config.Templates["ci"].DependsOn)
+		}
+	})
+
+	t.Run("unknown dependency", func(t *testing.T) {
+		tempFile := createTempConfigFile(t, `
+templates:
+  ci:
+    template-url: "https://example.com/ci"
+    output-folder: "./app/ci"
+    depends-on: [scaffold]
+`)
+
+		_, err := LoadConfig(tempFile)
+		if err == nil {
+			t.Fatal("Expected error for unknown dependency")
+		}
+		if !strings.Contains(err.Error(), "depends-on references unknown template 'scaffold'") {
+			t.Errorf("Expected unknown dependency error, got: %v", err)
+		}
+	})
+}
+
+func TestLoadConfigInterpolationIsStructureSafe(t *testing.T) {
+	configContent := `
+templates:
+  web:
+    template-url: "${TEMPLATE_REPO}/web"
+    output-folder: ./web
+    non-interactive: ${NON_INTERACTIVE}
+    vars:
+      secret: ${SECRET}
+      quoted: "${QUOTE}"
+      multiline: ${MULTILINE}
+      injected: ${INJECTION}
+      # ${NOT_A_VALUE:?comments are not interpolated}
+`
+	tempFile := createTempConfigFile(t, configContent)
+
+	envManager := NewEnvironmentManager()
+	envManager.SetVariable("TEMPLATE_REPO", "https://github.com/test")
+	envManager.SetVariable("NON_INTERACTIVE", "true")
+	envManager.SetVariable("SECRET", "p@ss: word # not a comment")
+	envManager.SetVariable("QUOTE", `it's "quoted"`)
+	envManager.SetVariable("MULTILINE", "line one\nline two")
+	envManager.SetVariable("INJECTION", "x\n    no-shell: true")
+
+	config, err := LoadConfigWithEnvironment(tempFile, envManager)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	web := config.Templates["web"]
+	if !web.NonInteractive {
+		t.Error("Expected unquoted substituted value to decode as a boolean")
+	}
+	if web.NoShell {
+		t.Error("Expected substituted value not to inject keys")
+	}
+
+	expected := map[string]string{
+		"secret":    "p@ss: word # not a comment",
+		"quoted":    `it's "quoted"`,
+		"multiline": "line one\nline two",
+		"injected":  "x\n    no-shell: true",
+	}
+	if !reflect.DeepEqual(web.Vars, expected) {
+		t.Errorf("Expected vars %q, got %q", expected, web.Vars)
+	}
+}
+
+func TestLoadConfigStrict(t *testing.T) {
+	configContent := `
+templates:
+  web:
+    template-url: "${TEMPLATE_REPO}/web"
+    output-folder: "./web"
+    vars:
+      project_name: "${PROJECT_NAME}"
+      title: "$TITLE by ${AUTHOR:-anonymous}"
+      price: "$$5"
+    var-file:
+      - "${VARS_DIR}/web.yaml"
+`
+	envManager := NewEnvironmentManager()
+	envManager.SetVariable("TEMPLATE_REPO", "https://github.com/test")
+
+	t.Run("lenient by default", func(t *testing.T) {
+		tempFile := createTempConfigFile(t, configContent)
+
+		config, err := LoadConfigWithEnvironment(tempFile, envManager)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if config.Templates["web"].Vars["project_name"] != "${PROJECT_NAME}" {
+			t.Errorf("Expected unresolved reference to be kept, got %q", config.Templates["web"].Vars["project_name"])
+		}
+	})
+
+	t.Run("strict option lists every missing variable", func(t *testing.T) {
+		tempFile := createTempConfigFile(t, configContent)
+
+		_, err := LoadConfigWithOptions(tempFile, LoadOptions{Environment: envManager, Strict: true})
+		if err == nil {
+			t.Fatal("Expected error in strict mode")
+		}
+
+		for _, want := range []string{
+			"3 unresolved variable reference(s) in strict mode",
+			"PROJECT_NAME in template 'web' field 'vars.project_name' (" + tempFile + ":7:21)",
+			"TITLE in template 'web' field 'vars.title'",
+			"VARS_DIR in template 'web' field 'var-file[0]'",
+		} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected error to contain %q, got:\n%v", want, err)
+			}
+		}
+		if strings.Contains(err.Error(), "AUTHOR") {
+			t.Errorf("Expected variables with defaults not to be reported, got:\n%v", err)
+		}
+	})
+
+	t.Run("strict setting in the compose file", func(t *testing.T) {
+		tempFile := createTempConfigFile(t, "strict: true\n"+configContent)
+
+		_, err := LoadConfigWithEnvironment(tempFile, envManager)
+		if err == nil || !strings.Contains(err.Error(), "strict mode") {
+			t.Errorf("Expected strict mode error, got: %v", err)
+		}
+	})
+
+	t.Run("extended files are checked", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "boilerplate-compose.yaml")
+		basePath := filepath.Join(dir, "base.yaml")
+
+		writeConfigFile(t, basePath, `
+templates:
+  service-base:
+    template-url: "${MISSING_URL}/service"
+    output-folder: ./base
+`)
+		writeConfigFile(t, configPath, `
+strict: true
+templates:
+  api:
+    extends:
+      file: base.yaml
+      template: service-base
+    output-folder: ./api
+`)
+
+		_, err := LoadConfigWithEnvironment(configPath, envManager)
+		if err == nil {
+			t.Fatal("Expected error in strict mode")
+		}
+		want := "MISSING_URL in template 'service-base' field 'template-url' (" + basePath + ":4:"
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got:\n%v", want, err)
+		}
+	})
+}
+
+func TestLoadConfigRetry(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "boilerplate-compose.yaml")
+
+	writeConfigFile(t, configPath, `
+retry:
+  attempts: 2
+templates:
+  app:
+    template-url: git@github.com:example/app.git
+    output-folder: ./app
+    retry:
+      attempts: 4
+      backoff: 5s
+      on-exit-codes: [128]
+      on-stderr-match: ["Could not resolve host"]
+`)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	expected := &RetryConfig{
+		Attempts:      4,
+		Backoff:       Duration(5 * time.Second),
+		OnExitCodes:   []int{128},
+		OnStderrMatch: []string{"Could not resolve host"},
+	}
+	if !reflect.DeepEqual(cfg.Templates["app"].Retry, expected) {
+		t.Errorf("unexpected retry %+v", cfg.Templates["app"].Retry)
+	}
+	if cfg.Retry == nil || cfg.Retry.Attempts != 2 {
+		t.Errorf("unexpected default retry %+v", cfg.Retry)
+	}
+
+	invalid := []struct {
+		retry    string
+		expected string
+	}{
+		{"{attempts: 0}", "template 'app': retry: attempts must be at least 1"},
+		{"{attempts: 2, backoff: -1s}", "retry: backoff must not be negative"},
+		{`{attempts: 2, on-stderr-match: ["("]}`, "retry: invalid on-stderr-match pattern '('"},
+	}
+	for _, tt := range invalid {
+		writeConfigFile(t, configPath, `
+templates:
+  app:
+    template-url: ./app
+    output-folder: ./app
+    retry: `+tt.retry+`
+`)
+		if _, err := LoadConfig(configPath); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("retry %s: expected error containing %q, got %v", tt.retry, tt.expected, err)
+		}
+	}
+}
+
+func TestLoadConfigEnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "compose.yaml")
+	writeConfigFile(t, filepath.Join(dir, "defaults.env"), "TEMPLATE_REPO=https://example.com/defaults\nOWNER=platform\n")
+	writeConfigFile(t, filepath.Join(dir, "override.env"), "TEMPLATE_REPO=https://example.com/override\n")
+
+	load := func(content string, envFiles ...string) (*ComposeConfig, error) {
+		t.Helper()
+		writeConfigFile(t, configPath, content)
+		return LoadConfigWithOptions(configPath, LoadOptions{Environment: NewEnvironmentManager(), EnvFiles: envFiles})
+	}
+	const templates = `
+templates:
+  app:
+    template-url: "${TEMPLATE_REPO}/app"
+    output-folder: ./app
+    vars:
+      owner: "${OWNER}"
+`
+
+	t.Run("env_file relative to the compose file", func(t *testing.T) {
+		cfg, err := load("env_file: defaults.env" + templates)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		app := cfg.Templates["app"]
+		if app.TemplateURL != "https://example.com/defaults/app" || app.Vars["owner"] != "platform" {
+			t.Errorf("Expected values from defaults.env, got %+v", app)
+		}
+	})
+
+	t.Run("loader env files win over env_file", func(t *testing.T) {
+		cfg, err := load("env_file:\n  - defaults.env"+templates, filepath.Join(dir, "override.env"))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		app := cfg.Templates["app"]
+		if app.TemplateURL != "https://example.com/override/app" || app.Vars["owner"] != "platform" {
+			t.Errorf("Expected override.env to win, got %+v", app)
+		}
+	})
+
+	t.Run("missing env file", func(t *testing.T) {
+		_, err := load("env_file: missing.env" + templates)
+		if err == nil || !strings.Contains(err.Error(), "failed to load environment file") {
+			t.Errorf("Expected an environment file error, got: %v", err)
+		}
+	})
+
+	t.Run("missing compose file", func(t *testing.T) {
+		_, err := LoadConfigWithOptions(filepath.Join(dir, "missing.yaml"), LoadOptions{Environment: NewEnvironmentManager()})
+		if err == nil || !strings.Contains(err.Error(), "config file not found") {
+			t.Errorf("Expected 'config file not found' error, got: %v", err)
+		}
+	})
+
+	t.Run("syntax errors are not env_file problems", func(t *testing.T) {
+		_, err := load("env_file: defaults.env\ntemplates: [\n")
+		if err == nil || strings.Contains(err.Error(), "env") || !strings.Contains(err.Error(), "failed to parse YAML") {
+			t.Errorf("Expected a YAML syntax error, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Normalized returns a copy of the config without the include, extends and
+// env_file keys. The loader has already applied them, so the copy describes
+// exactly the templates that will run.
+func (c *ComposeConfig) Normalized() *ComposeConfig {
+	normalized := *c
+	normalized.Include = nil
+	normalized.Extends = nil
+	normalized.EnvFile = nil
+	return &normalized
+}
+
+// MarshalYAML encodes the config with templates in declaration order rather
+// than the alphabetical order of a plain map.
+func (c *ComposeConfig) MarshalYAML() (interface{}, error) {
+	type plain ComposeConfig
+	var node yaml.Node
+	if err := node.Encode((*plain)(c)); err != nil {
+		return nil, err
+	}
+
+	templates := mappingValue(&node, "templates")
+	if templates == nil || templates.Kind != yaml.MappingNode {
+		return &node, nil
+	}
+
+	entries := make(map[string][]*yaml.Node, len(templates.Content)/2)
+	for i := 0; i+1 < len(templates.Content); i += 2 {
+		entries[templates.Content[i].Value] = templates.Content[i : i+2]
+	}
+
+	content := make([]*yaml.Node, 0, len(templates.Content))
+	for _, name := range c.TemplateNames() {
+		content = append(content, entries[name]...)
+	}
+	templates.Content = content
+
+	return &node, nil
+}
+
+// MarshalJSON encodes the config as JSON using the same keys and template
+// order as MarshalYAML.
+func (c *ComposeConfig) MarshalJSON() ([]byte, error) {
+	node, err := c.MarshalYAML()
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := writeJSON(&b, node.(*yaml.Node)); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// writeJSON writes a YAML node as JSON, keeping the order of mapping keys.
+func writeJSON(b *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		return writeJSON(b, node.Content[0])
+
+	case yaml.AliasNode:
+		return writeJSON(b, node.Alias)
+
+	case yaml.MappingNode:
+		b.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			key, err := json.Marshal(node.Content[i].Value)
+			if err != nil {
+				return err
+			}
+			b.Write(key)
+			b.WriteByte(':')
+			if err := writeJSON(b, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+
+	case yaml.SequenceNode:
+		b.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeJSON(b, item); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+
+	default:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestComposeConfigMarshalKeepsTemplateOrder(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "boilerplate-compose.yaml")
+	writeConfigFile(t, filepath.Join(dir, "base.yaml"), `
+templates:
+  base:
+    template-url: ./base
+    output-folder: ./base
+`)
+	writeConfigFile(t, configPath, `
+include: [base.yaml]
+env_file: .env.shared
+templates:
+  zeta:
+    extends: base
+    output-folder: ./zeta
+    vars:
+      port: "8080"
+  alpha:
+    template-url: ./alpha
+    output-folder: ./alpha
+    non-interactive: true
+`)
+	writeConfigFile(t, filepath.Join(dir, ".env.shared"), "")
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	out, err := yaml.Marshal(cfg.Normalized())
+	if err != nil {
+		t.Fatalf("yaml.Marshal() error = %v", err)
+	}
+
+	expected := `templates:
+    base:
+        template-url: ./base
+        output-folder: ./base
+    zeta:
+        template-url: ./base
+        output-folder: ./zeta
+        vars:
+            port: "8080"
+    alpha:
+        template-url: ./alpha
+        output-folder: ./alpha
+        non-interactive: true
+`
+	if string(out) != expected {
+		t.Errorf("unexpected YAML:\n%s\nexpected:\n%s", out, expected)
+	}
+
+	data, err := json.Marshal(cfg.Normalized())
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	expectedJSON := `{"templates":{"base":{"template-url":"./base","output-folder":"./base"},` +
+		`"zeta":{"template-url":"./base","output-folder":"./zeta","vars":{"port":"8080"}},` +
+		`"alpha":{"template-url":"./alpha","output-folder":"./alpha","non-interactive":true}}}`
+	if string(data) != expectedJSON {
+		t.Errorf("unexpected JSON:\n%s\nexpected:\n%s", data, expectedJSON)
+	}
+}
+
+func TestNormalizedDropsAppliedKeys(t *testing.T) {
+	cfg := &ComposeConfig{
+		Templates: map[string]Template{"a": {TemplateURL: "./a", OutputFolder: "./a"}},
+		Include:   []IncludeConfig{{Path: "other.yaml"}},
+		Extends:   &ExtendsConfig{Template: "base"},
+		EnvFile:   StringList{".env"},
+		Strict:    true,
+	}
+
+	out, err := yaml.Marshal(cfg.Normalized())
+	if err != nil {
+		t.Fatalf("yaml.Marshal() error = %v", err)
+	}
+
+	for _, key := range []string{"include:", "extends:", "env_file:"} {
+		if strings.Contains(string(out), key) {
+			t.Errorf("expected %s to be omitted, got:\n%s", key, out)
+		}
+	}
+	if !strings.Contains(string(out), "strict: true") {
+		t.Errorf("expected strict to be kept, got:\n%s", out)
+	}
+	if cfg.Include == nil || cfg.Extends == nil || cfg.EnvFile == nil {
+		t.Error("Normalized() modified the original config")
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ResolveOutputFolder resolves a template's output folder relative to the
+// directory of the compose file. Absolute folders are returned unchanged.
+func ResolveOutputFolder(configPath, outputFolder string) string {
+	if filepath.IsAbs(outputFolder) {
+		return outputFolder
+	}
+	return filepath.Join(filepath.Dir(configPath), outputFolder)
+}
+
+// remoteTemplatePrefixes are template URL prefixes that boilerplate fetches
+// instead of reading from disk.
+var remoteTemplatePrefixes = []string{"git@", "github.com/", "gitlab.com/", "bitbucket.org/"}
+
+// IsLocalTemplateURL reports whether a template URL refers to a directory on
+// disk rather than a remote source such as a git repository or HTTP URL.
+func IsLocalTemplateURL(templateURL string) bool {
+	if strings.Contains(templateURL, "://") || strings.Contains(templateURL, "::") {
+		return false
+	}
+	for _, prefix := range remoteTemplatePrefixes {
+		if strings.HasPrefix(templateURL, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// WithResolvedPaths returns a copy of the config with every path made
+// absolute the way it is used when templates run: output folders relative to
+// the compose file, and local template URLs and var files relative to the
+// current directory, where boilerplate is started.
+func (c *ComposeConfig) WithResolvedPaths(configPath string) (*ComposeConfig, error) {
+	resolved := *c
+	resolved.Templates = make(map[string]Template, len(c.Templates))
+
+	for name, template := range c.Templates {
+		outputFolder, err := filepath.Abs(ResolveOutputFolder(configPath, template.OutputFolder))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve output-folder of template '%s': %w", name, err)
+		}
+		template.OutputFolder = outputFolder
+
+		if template.TemplateURL != "" && IsLocalTemplateURL(template.TemplateURL) {
+			if template.TemplateURL, err = filepath.Abs(template.TemplateURL); err != nil {
+				return nil, fmt.Errorf("failed to resolve template-url of template '%s': %w", name, err)
+			}
+		}
+
+		if template.VarFile, err = absVarFiles(template.VarFile); err != nil {
+			return nil, fmt.Errorf("failed to resolve var-file of template '%s': %w", name, err)
+		}
+
+		resolved.Templates[name] = template
+	}
+
+	return &resolved, nil
+}
+
+func absVarFiles(varFile interface{}) (interface{}, error) {
+	switch v := varFile.(type) {
+	case string:
+		return filepath.Abs(v)
+	case []interface{}:
+		files := make([]interface{}, len(v))
+		for i, file := range v {
+			files[i] = file
+			if path, ok := file.(string); ok {
+				abs, err := filepath.Abs(path)
+				if err != nil {
+					return nil, err
+				}
+				files[i] = abs
+			}
+		}
+		return files, nil
+	}
+	return varFile, nil
+}
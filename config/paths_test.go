@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveOutputFolder(t *testing.T) {
+	tests := []struct {
+		configPath string
+		folder     string
+		expected   string
+	}{
+		{"boilerplate-compose.yaml", "./out", "out"},
+		{"project/boilerplate-compose.yaml", "./out", "project/out"},
+		{"project/boilerplate-compose.yaml", "../out", "out"},
+		{"project/boilerplate-compose.yaml", "/abs/out", "/abs/out"},
+	}
+
+	for _, tt := range tests {
+		if got := ResolveOutputFolder(tt.configPath, tt.folder); got != tt.expected {
+			t.Errorf("ResolveOutputFolder(%q, %q) = %q, want %q", tt.configPath, tt.folder, got, tt.expected)
+		}
+	}
+}
+
+func TestIsLocalTemplateURL(t *testing.T) {
+	tests := map[string]bool{
+		"./templates/app":                         true,
+		"/srv/templates/app":                      true,
+		"templates/app":                           true,
+		"https://github.com/example/template":     false,
+		"git::https://example.com/template.git":   false,
+		"git@github.com:example/template.git":     false,
+		"github.com/example/template//app?ref=v1": false,
+	}
+
+	for url, expected := range tests {
+		if got := IsLocalTemplateURL(url); got != expected {
+			t.Errorf("IsLocalTemplateURL(%q) = %v, want %v", url, got, expected)
+		}
+	}
+}
+
+func TestWithResolvedPaths(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &ComposeConfig{
+		Templates: map[string]Template{
+			"local": {
+				TemplateURL:  "./templates/app",
+				OutputFolder: "./out",
+				VarFile:      []interface{}{"vars.yaml", "/etc/vars.yaml"},
+			},
+			"remote": {
+				TemplateURL:  "https://github.com/example/template",
+				OutputFolder: "/srv/out",
+				VarFile:      "vars.yaml",
+			},
+		},
+	}
+
+	resolved, err := cfg.WithResolvedPaths("project/boilerplate-compose.yaml")
+	if err != nil {
+		t.Fatalf("WithResolvedPaths() error = %v", err)
+	}
+
+	local := resolved.Templates["local"]
+	if local.TemplateURL != filepath.Join(cwd, "templates/app") {
+		t.Errorf("unexpected template-url %q", local.TemplateURL)
+	}
+	if local.OutputFolder != filepath.Join(cwd, "project/out") {
+		t.Errorf("unexpected output-folder %q", local.OutputFolder)
+	}
+	expectedVarFiles := []interface{}{filepath.Join(cwd, "vars.yaml"), "/etc/vars.yaml"}
+	if !reflect.DeepEqual(local.VarFile, expectedVarFiles) {
+		t.Errorf("unexpected var-file %v", local.VarFile)
+	}
+
+	remote := resolved.Templates["remote"]
+	if remote.TemplateURL != "https://github.com/example/template" {
+		t.Errorf("remote template-url should be unchanged, got %q", remote.TemplateURL)
+	}
+	if remote.OutputFolder != "/srv/out" {
+		t.Errorf("unexpected output-folder %q", remote.OutputFolder)
+	}
+	if remote.VarFile != filepath.Join(cwd, "vars.yaml") {
+		t.Errorf("unexpected var-file %v", remote.VarFile)
+	}
+
+	if cfg.Templates["local"].OutputFolder != "./out" {
+		t.Error("WithResolvedPaths() modified the original config")
+	}
+}
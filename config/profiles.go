@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ProfilesEnvVar names the environment variable that lists the profiles to
+// enable, separated by commas, when none are given on the command line.
+const ProfilesEnvVar = "BOILERPLATE_COMPOSE_PROFILES"
+
+// AllProfiles enables every profile when given as an active profile.
+const AllProfiles = "*"
+
+var profileNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ParseProfiles splits a comma-separated list of profile names, ignoring
+// surrounding whitespace and empty entries.
+func ParseProfiles(value string) []string {
+	var profiles []string
+	for _, profile := range strings.Split(value, ",") {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+// Enabled reports whether the template runs with the given active profiles.
+// Templates without profiles always run; others run when one of their
+// profiles is active, or when all profiles are enabled with "*".
+func (t Template) Enabled(active []string) bool {
+	if len(t.Profiles) == 0 {
+		return true
+	}
+
+	for _, name := range active {
+		if name == AllProfiles {
+			return true
+		}
+		for _, profile := range t.Profiles {
+			if profile == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func validateProfiles(profiles []string) error {
+	for _, profile := range profiles {
+		if !profileNamePattern.MatchString(profile) {
+			return fmt.Errorf("invalid profile name '%s'", profile)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseProfiles(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []string
+	}{
+		{"", nil},
+		{"ci", []string{"ci"}},
+		{"ci, docs,,observability ", []string{"ci", "docs", "observability"}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseProfiles(tt.value); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("ParseProfiles(%q) = %v, want %v", tt.value, got, tt.expected)
+		}
+	}
+}
+
+func TestTemplateEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles []string
+		active   []string
+		expected bool
+	}{
+		{"no profiles always runs", nil, nil, true},
+		{"no profiles with active profile", nil, []string{"ci"}, true},
+		{"inactive profile", []string{"docs"}, nil, false},
+		{"other profile active", []string{"docs"}, []string{"ci"}, false},
+		{"one of several active", []string{"ci", "docs"}, []string{"docs"}, true},
+		{"all profiles", []string{"docs"}, []string{AllProfiles}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := Template{Profiles: tt.profiles}
+			if got := template.Enabled(tt.active); got != tt.expected {
+				t.Errorf("Enabled(%v) = %v, want %v", tt.active, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadConfigProfiles(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "boilerplate-compose.yaml")
+
+	writeConfigFile(t, configPath, `
+templates:
+  docs:
+    template-url: ./docs
+    output-folder: ./docs
+    profiles: [docs, "bad profile"]
+`)
+
+	_, err := LoadConfig(configPath)
+	if err == nil || !strings.Contains(err.Error(), "template 'docs': invalid profile name 'bad profile'") {
+		t.Errorf("expected invalid profile error, got %v", err)
+	}
+
+	writeConfigFile(t, configPath, `
+templates:
+  docs:
+    template-url: ./docs
+    output-folder: ./docs
+    profiles: [docs]
+`)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Templates["docs"].Profiles, []string{"docs"}) {
+		t.Errorf("unexpected profiles %v", cfg.Templates["docs"].Profiles)
+	}
+}
@@ -0,0 +1,253 @@
+package config
+
+import (
+	_ "embed"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed schema.json
+var jsonSchema []byte
+
+// JSONSchema returns the JSON Schema of the compose file format, for use by
+// editors and other tools.
+func JSONSchema() []byte {
+	return jsonSchema
+}
+
+// extensionPrefix marks keys that are ignored wherever a field is expected,
+// so they can hold YAML anchors and other shared definitions.
+const extensionPrefix = "x-"
+
+var (
+	durationType   = reflect.TypeOf(Duration(0))
+	stringListType = reflect.TypeOf(StringList(nil))
+	includeType    = reflect.TypeOf(IncludeConfig{})
+	extendsType    = reflect.TypeOf(ExtendsConfig{})
+	templateType   = reflect.TypeOf(Template{})
+)
+
+// enumFields lists, by struct type and key, the values accepted by fields
+// that only allow a fixed set of values.
+var enumFields = map[reflect.Type]map[string][]string{
+	reflect.TypeOf(ComposeConfig{}): {
+		"collision-policy": CollisionPolicies,
+	},
+	templateType: {
+		"missing-key-action":    MissingKeyActions,
+		"missing-config-action": MissingConfigActions,
+	},
+}
+
+// checkSchema checks a parsed compose file against the config types before
+// it is decoded. Unlike decoding, it reports unknown fields, and it reports
+// every problem with its position rather than stopping at the first one.
+func checkSchema(file string, doc *yaml.Node) Diagnostics {
+	root := documentRoot(doc)
+	if root == nil {
+		return nil
+	}
+
+	c := &schemaChecker{file: file}
+	c.check(root, reflect.TypeOf(ComposeConfig{}), nil)
+	return c.diagnostics
+}
+
+type schemaChecker struct {
+	file        string
+	diagnostics Diagnostics
+}
+
+// check checks that node can be decoded into a value of type t. path holds
+// the keys leading to node.
+func (c *schemaChecker) check(node *yaml.Node, t reflect.Type, path []string) {
+	if node.Kind == yaml.AliasNode {
+		// Anchors are often defined under extension keys, which are not
+		// checked, so the anchored value is checked where it is used
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null" {
+		return
+	}
+
+	switch t {
+	case durationType:
+		if c.expect(node, yaml.ScalarNode, path, "a duration such as \"90s\"") {
+			if _, err := time.ParseDuration(node.Value); err != nil {
+				c.report(node, path, "invalid duration %q", node.Value)
+			}
+		}
+		return
+	case stringListType:
+		c.checkStringOrList(node, path)
+		return
+	case includeType, extendsType:
+		// Both also have a short form that is a single string
+		if node.Kind == yaml.ScalarNode {
+			return
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		c.check(node, t.Elem(), path)
+	case reflect.Struct:
+		c.checkStruct(node, t, path)
+	case reflect.Map:
+		if c.expect(node, yaml.MappingNode, path, "a mapping") {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				c.check(node.Content[i+1], t.Elem(), appendPath(path, node.Content[i].Value))
+			}
+		}
+	case reflect.Slice:
+		if c.expect(node, yaml.SequenceNode, path, "a list") {
+			for i, item := range node.Content {
+				c.check(item, t.Elem(), appendPath(path, fmt.Sprintf("[%d]", i)))
+			}
+		}
+	case reflect.Interface:
+		// The only untyped field is var-file
+		c.checkStringOrList(node, path)
+	case reflect.String:
+		c.expect(node, yaml.ScalarNode, path, "a string")
+	case reflect.Bool:
+		if c.expect(node, yaml.ScalarNode, path, "a boolean") && node.ShortTag() != "!!bool" {
+			c.report(node, path, "must be a boolean, got %q", node.Value)
+		}
+	case reflect.Int:
+		if c.expect(node, yaml.ScalarNode, path, "an integer") && node.ShortTag() != "!!int" {
+			c.report(node, path, "must be an integer, got %q", node.Value)
+		}
+	}
+}
+
+func (c *schemaChecker) checkStruct(node *yaml.Node, t reflect.Type, path []string) {
+	if !c.expect(node, yaml.MappingNode, path, "a mapping") {
+		return
+	}
+
+	fields := yamlFields(t)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		if key.ShortTag() == "!!merge" {
+			// Merged mappings must hold fields of the same struct
+			if value.Kind == yaml.SequenceNode {
+				for _, item := range value.Content {
+					c.check(item, t, path)
+				}
+			} else {
+				c.check(value, t, path)
+			}
+			continue
+		}
+		if strings.HasPrefix(key.Value, extensionPrefix) {
+			continue
+		}
+
+		field, ok := fields[key.Value]
+		if !ok {
+			c.report(key, path, "%s", unknownFieldMessage(key.Value, fields))
+			continue
+		}
+
+		fieldPath := appendPath(path, key.Value)
+		c.check(value, field.Type, fieldPath)
+
+		if allowed, ok := enumFields[t][key.Value]; ok && value.Kind == yaml.ScalarNode {
+			if value.ShortTag() != "!!null" && !slices.Contains(allowed, value.Value) {
+				c.report(value, fieldPath, "must be one of %s, got %q", strings.Join(allowed, ", "), value.Value)
+			}
+		}
+	}
+}
+
+func (c *schemaChecker) checkStringOrList(node *yaml.Node, path []string) {
+	if node.Kind == yaml.ScalarNode {
+		return
+	}
+	if c.expect(node, yaml.SequenceNode, path, "a string or a list of strings") {
+		for i, item := range node.Content {
+			c.check(item, reflect.TypeOf(""), appendPath(path, fmt.Sprintf("[%d]", i)))
+		}
+	}
+}
+
+// expect reports node unless it is of the given kind
+func (c *schemaChecker) expect(node *yaml.Node, kind yaml.Kind, path []string, description string) bool {
+	if node.Kind == kind {
+		return true
+	}
+	c.report(node, path, "must be %s", description)
+	return false
+}
+
+func (c *schemaChecker) report(node *yaml.Node, path []string, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if location := describePath(path); location != "" {
+		message = location + ": " + message
+	}
+	c.diagnostics.add(newDiagnostic(c.file, node, "%s", message))
+}
+
+// describePath describes the position of a value, naming the template it
+// belongs to, such as "template 'app': retry.attempts".
+func describePath(path []string) string {
+	if len(path) >= 2 && path[0] == "templates" {
+		template := fmt.Sprintf("template '%s'", path[1])
+		if len(path) == 2 {
+			return template
+		}
+		return template + ": " + joinPath(path[2:])
+	}
+	return joinPath(path)
+}
+
+func joinPath(path []string) string {
+	return strings.ReplaceAll(strings.Join(path, "."), ".[", "[")
+}
+
+// appendPath returns a new path, so sibling paths never share a backing array
+func appendPath(path []string, key string) []string {
+	return append(append(make([]string, 0, len(path)+1), path...), key)
+}
+
+// yamlFields returns the exported fields of a struct type by YAML key
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// unknownFieldMessage suggests the known field a key most likely meant, for
+// keys that differ only in case or in using '_' instead of '-'
+func unknownFieldMessage(key string, fields map[string]reflect.StructField) string {
+	normalize := func(s string) string {
+		return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(s))
+	}
+
+	for name := range fields {
+		if normalize(name) == normalize(key) {
+			return fmt.Sprintf("unknown field '%s' (did you mean '%s'?)", key, name)
+		}
+	}
+	return fmt.Sprintf("unknown field '%s'", key)
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestJSONSchemaMatchesTypes(t *testing.T) {
+	var schema struct {
+		Properties  map[string]json.RawMessage `json:"properties"`
+		Definitions map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal(JSONSchema(), &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		properties map[string]json.RawMessage
+		typ        reflect.Type
+	}{
+		{"compose file", schema.Properties, reflect.TypeOf(ComposeConfig{})},
+		{"template", schema.Definitions["template"].Properties, templateType},
+		{"retry", schema.Definitions["retry"].Properties, reflect.TypeOf(RetryConfig{})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inSchema, inType []string
+			for name := range tt.properties {
+				inSchema = append(inSchema, name)
+			}
+			for name := range yamlFields(tt.typ) {
+				inType = append(inType, name)
+			}
+			sort.Strings(inSchema)
+			sort.Strings(inType)
+
+			if !reflect.DeepEqual(inSchema, inType) {
+				t.Errorf("schema properties %v do not match fields %v", inSchema, inType)
+			}
+		})
+	}
+}
+
+func TestLoadConfigDiagnostics(t *testing.T) {
+	t.Run("reports every problem with its position", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "compose.yaml")
+		content := `verison: 2
+templates:
+  app:
+    template-url: ./app
+    output-folder: ./app
+    non_interactive: true
+    missing-key-action: ignore
+    depends-on: [db]
+  web:
+    template-url: ./web
+`
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := LoadConfig(configPath)
+		var diagnostics Diagnostics
+		if !errors.As(err, &diagnostics) {
+			t.Fatalf("expected diagnostics, got %v", err)
+		}
+
+		expected := []string{
+			configPath + ":1:1: unknown field 'verison'",
+			configPath + ":6:5: template 'app': unknown field 'non_interactive' (did you mean 'non-interactive'?)",
+			configPath + `:7:25: template 'app': missing-key-action: must be one of invalid, zero, error, got "ignore"`,
+			configPath + ":8:18: template 'app': depends-on references unknown template 'db'",
+			configPath + ":10:5: template 'web': output-folder is required",
+		}
+		var got []string
+		for _, d := range diagnostics {
+			got = append(got, d.String())
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("unexpected diagnostics:\n got: %q\nwant: %q", got, expected)
+		}
+	})
+
+	t.Run("reports type errors instead of decoding", func(t *testing.T) {
+		configPath := createTempConfigFile(t, `
+collision-policy: never
+templates:
+  app:
+    template-url: ./app
+    output-folder: ./app
+    no-hooks: "yes"
+    timeout: soon
+    vars:
+      list: [a, b]
+    retry:
+      attempts: many
+`)
+
+		_, err := LoadConfig(configPath)
+		var diagnostics Diagnostics
+		if !errors.As(err, &diagnostics) {
+			t.Fatalf("expected diagnostics, got %v", err)
+		}
+
+		expected := []string{
+			`collision-policy: must be one of error, warn, last-wins, first-wins, got "never"`,
+			`template 'app': no-hooks: must be a boolean, got "yes"`,
+			`template 'app': timeout: invalid duration "soon"`,
+			"template 'app': vars.list: must be a string",
+			`template 'app': retry.attempts: must be an integer, got "many"`,
+		}
+		var got []string
+		for _, d := range diagnostics {
+			got = append(got, d.Message)
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("unexpected diagnostics:\n got: %q\nwant: %q", got, expected)
+		}
+	})
+
+	t.Run("accepts extension keys and merged anchors", func(t *testing.T) {
+		configPath := createTempConfigFile(t, `
+version: "1.0"
+x-defaults: &defaults
+  non-interactive: true
+  missing-config-action: ignore
+templates:
+  app:
+    <<: *defaults
+    x-owner: platform-team
+    template-url: ./app
+    output-folder: ./app
+`)
+
+		config, err := LoadConfig(configPath)
+		if err != nil {
+			t.Fatalf("LoadConfig() error = %v", err)
+		}
+		if app := config.Templates["app"]; !app.NonInteractive || app.MissingConfigAction != "ignore" {
+			t.Errorf("expected merged defaults, got %+v", app)
+		}
+	})
+
+	t.Run("checks merged anchors", func(t *testing.T) {
+		configPath := createTempConfigFile(t, `
+x-defaults: &defaults
+  missing-config-action: skip
+templates:
+  app:
+    <<: *defaults
+    template-url: ./app
+    output-folder: ./app
+`)
+
+		_, err := LoadConfig(configPath)
+		if err == nil {
+			t.Fatal("expected error for invalid value in anchor")
+		}
+		expected := configPath + `:3:26: template 'app': missing-config-action: must be one of exit, ignore, got "skip"`
+		if err.Error() != "config validation failed: "+expected {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
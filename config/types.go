@@ -1,9 +1,85 @@
 package config
 
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
 type ComposeConfig struct {
+	// Version is informational; it is accepted but not interpreted.
+	Version   string              `yaml:"version,omitempty"`
 	Templates map[string]Template `yaml:"templates"`
 	Include   []IncludeConfig     `yaml:"include,omitempty"`
 	Extends   *ExtendsConfig      `yaml:"extends,omitempty"`
+	// Strict makes any variable reference that cannot be resolved an error.
+	Strict bool `yaml:"strict,omitempty"`
+	// EnvFile lists .env files, relative to the compose file, that are loaded
+	// before any file given on the command line.
+	EnvFile StringList `yaml:"env_file,omitempty"`
+	// Retry is the retry policy of templates that do not set their own.
+	Retry *RetryConfig `yaml:"retry,omitempty"`
+	// CollisionPolicy decides what happens when two templates write the
+	// same file. Defaults to DefaultCollisionPolicy.
+	CollisionPolicy CollisionPolicy `yaml:"collision-policy,omitempty"`
+
+	// sources maps each template name to the absolute path of the file that
+	// defines it. It is populated by the loader.
+	sources map[string]string
+	// order holds template names in declaration order, with templates from
+	// included files first. It is populated when decoding YAML.
+	order []string
+}
+
+// UnmarshalYAML decodes the config and records the order in which templates
+// are declared, which a plain map would lose.
+func (c *ComposeConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain ComposeConfig
+	if err := node.Decode((*plain)(c)); err != nil {
+		return err
+	}
+
+	c.order = nil
+	if templates := mappingValue(node, "templates"); templates != nil && templates.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(templates.Content); i += 2 {
+			c.order = append(c.order, templates.Content[i].Value)
+		}
+	}
+
+	return nil
+}
+
+// TemplateNames returns the names of all templates in declaration order.
+// Templates without a recorded position, such as those added in code, follow
+// in alphabetical order.
+func (c *ComposeConfig) TemplateNames() []string {
+	names := make([]string, 0, len(c.Templates))
+	seen := make(map[string]bool, len(c.Templates))
+
+	for _, name := range c.order {
+		if _, exists := c.Templates[name]; exists && !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+
+	var rest []string
+	for name := range c.Templates {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(names, rest...)
+}
+
+// SourceFile returns the path of the compose file that defined the named
+// template, or an empty string if it is unknown.
+func (c *ComposeConfig) SourceFile(name string) string {
+	return c.sources[name]
 }
 
 type Template struct {
@@ -17,13 +93,122 @@ type Template struct {
 	NoHooks                 bool              `yaml:"no-hooks,omitempty"`
 	NoShell                 bool              `yaml:"no-shell,omitempty"`
 	DisableDependencyPrompt bool              `yaml:"disable-dependency-prompt,omitempty"`
+	// DependsOn lists templates that must complete before this one runs.
+	DependsOn []string `yaml:"depends-on,omitempty"`
+	// Profiles makes the template optional: it only runs when one of these
+	// profiles is active. Templates without profiles always run.
+	Profiles []string `yaml:"profiles,omitempty"`
+	// AllowFailure lets the run continue, and succeed, when this template
+	// fails. Templates that depend on it are still skipped.
+	AllowFailure bool `yaml:"allow-failure,omitempty"`
+	// Timeout stops the template when it runs longer, overriding the
+	// default given on the command line.
+	Timeout Duration `yaml:"timeout,omitempty"`
+	// Retry runs the template again when it fails, for example because a
+	// remote template could not be fetched.
+	Retry *RetryConfig `yaml:"retry,omitempty"`
+	// Extends names a base template whose fields this template inherits.
+	// The loader resolves it and clears it on the returned templates.
+	Extends *ExtendsConfig `yaml:"extends,omitempty"`
+}
+
+// MissingKeyActions are the values boilerplate accepts for
+// missing-key-action.
+var MissingKeyActions = []string{"invalid", "zero", "error"}
+
+// MissingConfigActions are the values boilerplate accepts for
+// missing-config-action.
+var MissingConfigActions = []string{"exit", "ignore"}
+
+// RetryConfig describes when and how often a failed template is run again.
+// Without OnExitCodes and OnStderrMatch every failure is retried; otherwise
+// only failures with a listed exit code or matching stderr output are.
+type RetryConfig struct {
+	// Attempts is the total number of runs, including the first.
+	Attempts int `yaml:"attempts"`
+	// Backoff is the delay before the first retry. It doubles after every
+	// further attempt. Defaults to DefaultRetryBackoff.
+	Backoff Duration `yaml:"backoff,omitempty"`
+	// OnExitCodes lists the boilerplate exit codes that are retried.
+	OnExitCodes []int `yaml:"on-exit-codes,omitempty"`
+	// OnStderrMatch lists regular expressions; a failure is retried when
+	// any of them matches boilerplate's stderr output.
+	OnStderrMatch []string `yaml:"on-stderr-match,omitempty"`
+}
+
+// DefaultRetryBackoff is the delay before the first retry when a retry
+// policy does not set one.
+const DefaultRetryBackoff = Duration(time.Second)
+
+// Duration is a time.Duration written as a string such as "90s" or "5m".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid duration %q", node.Line, value)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+// StringList is a list of strings that may also be written as a single
+// string in YAML.
+type StringList []string
+
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = StringList{node.Value}
+		return nil
+	}
+
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
 }
 
 type IncludeConfig struct {
 	Path string `yaml:"path"`
 }
 
+// UnmarshalYAML accepts both the long form (`- path: file.yaml`) and the
+// short form (`- file.yaml`) of an include entry.
+func (i *IncludeConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		i.Path = node.Value
+		return nil
+	}
+
+	type plain IncludeConfig
+	return node.Decode((*plain)(i))
+}
+
 type ExtendsConfig struct {
 	File     string `yaml:"file"`
 	Template string `yaml:"template"`
-}
\ No newline at end of file
+}
+
+// UnmarshalYAML accepts both the long form (`extends: {file, template}`) and
+// the short form (`extends: base-template`) that refers to a template in the
+// same file.
+func (e *ExtendsConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		e.Template = node.Value
+		return nil
+	}
+
+	type plain ExtendsConfig
+	return node.Decode((*plain)(e))
+}
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -140,4 +143,107 @@ templates:
 			t.Errorf("Expected 2 var files, got %d", len(varFiles))
 		}
 	})
-}
\ No newline at end of file
+}
+func TestIncludeConfigShortSyntax(t *testing.T) {
+	yamlData := `
+include:
+  - "short.yaml"
+  - path: "long.yaml"
+`
+	var config ComposeConfig
+	if err := yaml.Unmarshal([]byte(yamlData), &config); err != nil {
+		t.Fatalf("Failed to unmarshal YAML: %v", err)
+	}
+
+	if len(config.Include) != 2 {
+		t.Fatalf("Expected 2 includes, got %d", len(config.Include))
+	}
+	if config.Include[0].Path != "short.yaml" {
+		t.Errorf("Expected include path 'short.yaml', got '%s'", config.Include[0].Path)
+	}
+	if config.Include[1].Path != "long.yaml" {
+		t.Errorf("Expected include path 'long.yaml', got '%s'", config.Include[1].Path)
+	}
+}
+
+func TestTemplateExtendsSyntax(t *testing.T) {
+	yamlData := `
+templates:
+  short:
+    extends: base
+  long:
+    extends:
+      file: "base.yaml"
+      template: "base"
+`
+	var config ComposeConfig
+	if err := yaml.Unmarshal([]byte(yamlData), &config); err != nil {
+		t.Fatalf("Failed to unmarshal YAML: %v", err)
+	}
+
+	short := config.Templates["short"].Extends
+	if short == nil || short.Template != "base" || short.File != "" {
+		t.Errorf("Expected short extends to reference 'base' in the same file, got %+v", short)
+	}
+
+	long := config.Templates["long"].Extends
+	if long == nil || long.Template != "base" || long.File != "base.yaml" {
+		t.Errorf("Expected long extends to reference 'base' in base.yaml, got %+v", long)
+	}
+}
+
+func TestTemplateNamesOrder(t *testing.T) {
+	t.Run("declaration order from YAML", func(t *testing.T) {
+		yamlData := `
+templates:
+  zeta:
+    template-url: "https://example.com/zeta"
+  alpha:
+    template-url: "https://example.com/alpha"
+  mid:
+    template-url: "https://example.com/mid"
+`
+		var config ComposeConfig
+		if err := yaml.Unmarshal([]byte(yamlData), &config); err != nil {
+			t.Fatalf("Failed to unmarshal YAML: %v", err)
+		}
+
+		expected := []string{"zeta", "alpha", "mid"}
+		if !reflect.DeepEqual(config.TemplateNames(), expected) {
+			t.Errorf("Expected %v, got %v", expected, config.TemplateNames())
+		}
+	})
+
+	t.Run("alphabetical order for templates built in code", func(t *testing.T) {
+		config := ComposeConfig{
+			Templates: map[string]Template{"b": {}, "c": {}, "a": {}},
+		}
+
+		expected := []string{"a", "b", "c"}
+		if !reflect.DeepEqual(config.TemplateNames(), expected) {
+			t.Errorf("Expected %v, got %v", expected, config.TemplateNames())
+		}
+	})
+}
+
+func TestDurationYAML(t *testing.T) {
+	var template Template
+	if err := yaml.Unmarshal([]byte("timeout: 1m30s"), &template); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if time.Duration(template.Timeout) != 90*time.Second {
+		t.Errorf("expected 90s, got %v", time.Duration(template.Timeout))
+	}
+
+	out, err := yaml.Marshal(Template{Timeout: Duration(5 * time.Minute)})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(out), "timeout: 5m0s") {
+		t.Errorf("unexpected YAML:\n%s", out)
+	}
+
+	if err := yaml.Unmarshal([]byte("timeout: soon"), &template); err == nil {
+		t.Error("expected an error for an invalid duration")
+	}
+}
@@ -2,13 +2,51 @@ package executor
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os/exec"
 	"strings"
+	"time"
 )
 
+// maxLineLength is the longest line of boilerplate output that is logged.
+const maxLineLength = 1024 * 1024
+
+// stderrTailLines is the number of trailing stderr lines kept for reports.
+const stderrTailLines = 20
+
+// killGracePeriod is how long a cancelled boilerplate process may take to
+// exit after SIGTERM before it is killed.
+const killGracePeriod = 5 * time.Second
+
+// CommandError is returned by Execute when boilerplate, or another command
+// run for the template such as git, exits with an error.
+type CommandError struct {
+	TemplateName string
+	// Command is the program that failed; boilerplate when empty.
+	Command string
+	// ExitCode is the exit code of the command, or -1 if it was not known.
+	ExitCode int
+	// Stderr holds the last lines the command wrote to stderr.
+	Stderr string
+	Err    error
+}
+
+func (e *CommandError) Error() string {
+	command := e.Command
+	if command == "" {
+		command = "boilerplate"
+	}
+	return fmt.Sprintf("%s command failed for template '%s': %v", command, e.TemplateName, e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
 type CliExecutor struct {
 	boilerplatePath string
 	verbose         bool
@@ -21,12 +59,18 @@ func NewCliExecutor(boilerplatePath string, verbose bool) *CliExecutor {
 	}
 }
 
-func (e *CliExecutor) Execute(args []string, templateName string) error {
-	if e.boilerplatePath == "" {
-		e.boilerplatePath = "boilerplate" // Default to PATH lookup
+// Execute runs boilerplate for one template. When ctx is cancelled or times
+// out, boilerplate and every process it started are terminated. It is safe
+// to call from multiple goroutines.
+func (e *CliExecutor) Execute(ctx context.Context, args []string, templateName string) error {
+	path := e.boilerplatePath
+	if path == "" {
+		path = "boilerplate" // Default to PATH lookup
 	}
 
-	cmd := exec.Command(e.boilerplatePath, args...)
+	cmd := exec.CommandContext(ctx, path, args...)
+	release := setProcessGroup(cmd)
+	defer release()
 
 	// Set up pipes for stdout and stderr
 	stdout, err := cmd.StdoutPipe()
@@ -40,7 +84,7 @@ func (e *CliExecutor) Execute(args []string, templateName string) error {
 	}
 
 	// Start the command
-	log.Printf("Executing template '%s': %s %s", templateName, e.boilerplatePath, strings.Join(args, " "))
+	log.Printf("Executing template '%s': %s %s", templateName, path, strings.Join(args, " "))
 
 	if err := cmd.Start(); err != nil {
 		return fmt.Errorf("failed to start boilerplate command: %w", err)
@@ -49,8 +93,9 @@ func (e *CliExecutor) Execute(args []string, templateName string) error {
 	// Stream output
 	done := make(chan error, 2)
 
-	go e.streamOutput(stdout, "STDOUT", templateName, done)
-	go e.streamOutput(stderr, "STDERR", templateName, done)
+	stderrTail := &lineTail{max: stderrTailLines}
+	go e.streamOutput(stdout, "STDOUT", templateName, nil, done)
+	go e.streamOutput(stderr, "STDERR", templateName, stderrTail, done)
 
 	// Wait for streaming to complete
 	for i := 0; i < 2; i++ {
@@ -61,19 +106,38 @@ func (e *CliExecutor) Execute(args []string, templateName string) error {
 
 	// Wait for command to complete
 	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf("boilerplate command failed for template '%s': %w", templateName, err)
+		exitCode := -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		}
+		if ctx.Err() != nil {
+			err = fmt.Errorf("%w (%v)", ctx.Err(), err)
+		}
+		return &CommandError{
+			TemplateName: templateName,
+			ExitCode:     exitCode,
+			Stderr:       stderrTail.String(),
+			Err:          err,
+		}
 	}
 
 	log.Printf("Template '%s' completed successfully", templateName)
 	return nil
 }
 
-func (e *CliExecutor) streamOutput(reader io.Reader, prefix string, templateName string, done chan error) {
-	defer func() { done <- nil }()
-
+// streamOutput logs the output of a boilerplate process line by line. Each
+// line is written with a single log call and prefixed with the template name,
+// so output from templates running in parallel never interleaves mid-line.
+// When tail is not nil, the last lines are also kept in it.
+func (e *CliExecutor) streamOutput(reader io.Reader, prefix string, templateName string, tail *lineTail, done chan error) {
 	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxLineLength)
 	for scanner.Scan() {
 		line := scanner.Text()
+		if tail != nil {
+			tail.add(line)
+		}
 		if e.verbose {
 			log.Printf("[%s][%s] %s", templateName, prefix, line)
 		} else if prefix == "STDERR" {
@@ -83,9 +147,29 @@ func (e *CliExecutor) streamOutput(reader io.Reader, prefix string, templateName
 	}
 
 	if err := scanner.Err(); err != nil {
+		// Drain the rest so the process does not block on a full pipe
+		_, _ = io.Copy(io.Discard, reader)
 		done <- fmt.Errorf("error reading %s: %w", prefix, err)
 		return
 	}
+	done <- nil
+}
+
+// lineTail keeps the last max lines added to it.
+type lineTail struct {
+	max   int
+	lines []string
+}
+
+func (t *lineTail) add(line string) {
+	t.lines = append(t.lines, line)
+	if len(t.lines) > t.max {
+		t.lines = t.lines[len(t.lines)-t.max:]
+	}
+}
+
+func (t *lineTail) String() string {
+	return strings.Join(t.lines, "\n")
 }
 
 func (e *CliExecutor) CheckBoilerplateAvailable() error {
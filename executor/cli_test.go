@@ -1,6 +1,13 @@
 package executor
 
 import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -33,7 +40,7 @@ func TestNewCliExecutor(t *testing.T) {
 func TestCliExecutor_Execute_InvalidCommand(t *testing.T) {
 	executor := NewCliExecutor("/nonexistent/boilerplate", false)
 	
-	err := executor.Execute([]string{"--help"}, "test-template")
+	err := executor.Execute(context.Background(), []string{"--help"}, "test-template")
 	
 	if err == nil {
 		t.Error("expected error when executing nonexistent command, got nil")
@@ -54,10 +61,38 @@ func TestCliExecutor_Execute_EmptyPath(t *testing.T) {
 	executor := NewCliExecutor("", false)
 	
 	// This should set boilerplatePath to "boilerplate" as fallback
-	err := executor.Execute([]string{"--help"}, "test-template")
+	err := executor.Execute(context.Background(), []string{"--help"}, "test-template")
 	
 	// We expect this to fail since boilerplate CLI is not installed in test env
 	if err == nil {
 		t.Error("expected error when boilerplate CLI not available, got nil")
 	}
-}
\ No newline at end of file
+}
+func TestCliExecutor_Execute_CommandError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "boilerplate")
+	script := "#!/bin/sh\nfor i in $(seq 1 30); do echo \"line $i\" >&2; done\nexit 4\n"
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	err := NewCliExecutor(path, false).Execute(context.Background(), nil, "test-template")
+
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("expected *CommandError, got %T: %v", err, err)
+	}
+	if cmdErr.ExitCode != 4 {
+		t.Errorf("expected exit code 4, got %d", cmdErr.ExitCode)
+	}
+
+	lines := strings.Split(cmdErr.Stderr, "\n")
+	if len(lines) != stderrTailLines || lines[0] != "line 11" || lines[len(lines)-1] != "line 30" {
+		t.Errorf("expected the last %d stderr lines, got %q", stderrTailLines, cmdErr.Stderr)
+	}
+	if !strings.Contains(err.Error(), "boilerplate command failed for template 'test-template'") {
+		t.Errorf("unexpected error message %q", err.Error())
+	}
+}
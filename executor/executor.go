@@ -0,0 +1,53 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+)
+
+// Executor renders templates with boilerplate.
+type Executor interface {
+	// Execute renders one template. args are boilerplate command line
+	// arguments. Rendering stops when ctx is cancelled or times out.
+	// Failures of boilerplate itself are returned as *CommandError.
+	Execute(ctx context.Context, args []string, templateName string) error
+	// CheckBoilerplateAvailable reports an error if templates cannot be
+	// rendered, for example because the boilerplate binary is missing.
+	CheckBoilerplateAvailable() error
+}
+
+// Engine selects the Executor implementation.
+type Engine string
+
+const (
+	// EngineCLI runs the boilerplate binary for every template.
+	EngineCLI Engine = "cli"
+	// EngineLibrary runs boilerplate in-process as a Go library.
+	EngineLibrary Engine = "library"
+)
+
+// ParseEngine validates an engine name
+func ParseEngine(name string) (Engine, error) {
+	switch e := Engine(name); e {
+	case EngineCLI, EngineLibrary:
+		return e, nil
+	}
+	return "", fmt.Errorf("invalid engine %q (expected %q or %q)", name, EngineCLI, EngineLibrary)
+}
+
+// New creates the executor for engine. boilerplatePath is only used by the
+// cli engine.
+func New(engine Engine, boilerplatePath string, verbose bool) (Executor, error) {
+	switch engine {
+	case EngineCLI, "":
+		return NewCliExecutor(boilerplatePath, verbose), nil
+	case EngineLibrary:
+		return NewLibraryExecutor(), nil
+	}
+	return nil, fmt.Errorf("invalid engine %q", engine)
+}
+
+var (
+	_ Executor = (*CliExecutor)(nil)
+	_ Executor = (*LibraryExecutor)(nil)
+)
@@ -0,0 +1,31 @@
+package executor
+
+import "testing"
+
+func TestParseEngine(t *testing.T) {
+	for _, name := range []string{"cli", "library"} {
+		engine, err := ParseEngine(name)
+		if err != nil || string(engine) != name {
+			t.Errorf("ParseEngine(%q) = %q, %v", name, engine, err)
+		}
+	}
+
+	if _, err := ParseEngine("docker"); err == nil {
+		t.Error("expected an error for an unknown engine")
+	}
+}
+
+func TestNewCli(t *testing.T) {
+	exec, err := New(EngineCLI, "/usr/local/bin/boilerplate", true)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	cli, ok := exec.(*CliExecutor)
+	if !ok {
+		t.Fatalf("expected *CliExecutor, got %T", exec)
+	}
+	if cli.boilerplatePath != "/usr/local/bin/boilerplate" || !cli.verbose {
+		t.Errorf("unexpected executor %+v", cli)
+	}
+}
@@ -0,0 +1,54 @@
+package executor
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	boilerplate "github.com/gruntwork-io/boilerplate/cli"
+)
+
+// LibraryExecutor renders templates in-process by running the boilerplate
+// command line app as a Go library, so no boilerplate binary is needed.
+type LibraryExecutor struct {
+	// mu serializes runs, since boilerplate keeps global state such as its
+	// logger and is not meant to run several templates at once
+	mu sync.Mutex
+}
+
+// NewLibraryExecutor creates an executor that runs boilerplate in-process.
+func NewLibraryExecutor() *LibraryExecutor {
+	return &LibraryExecutor{}
+}
+
+// Execute renders one template in-process. It is safe to call from multiple
+// goroutines, but templates are rendered one at a time. A template that has
+// started rendering cannot be interrupted; ctx only keeps it from starting.
+func (e *LibraryExecutor) Execute(ctx context.Context, args []string, templateName string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return &CommandError{TemplateName: templateName, ExitCode: -1, Err: err}
+	}
+
+	log.Printf("Executing template '%s' in-process: boilerplate %s", templateName, strings.Join(args, " "))
+
+	app := boilerplate.CreateBoilerplateCli()
+	if err := app.Run(append([]string{"boilerplate"}, args...)); err != nil {
+		return &CommandError{
+			TemplateName: templateName,
+			ExitCode:     -1,
+			Err:          err,
+		}
+	}
+
+	log.Printf("Template '%s' completed successfully", templateName)
+	return nil
+}
+
+// CheckBoilerplateAvailable always succeeds, since boilerplate is built in.
+func (e *LibraryExecutor) CheckBoilerplateAvailable() error {
+	return nil
+}
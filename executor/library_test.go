@@ -0,0 +1,55 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLibraryExecutor_Execute(t *testing.T) {
+	templateDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(templateDir, "boilerplate.yml"), []byte("variables:\n  - name: Name\n"), 0644); err != nil {
+		t.Fatalf("Failed to write boilerplate.yml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "hello.txt"), []byte("Hello, {{ .Name }}!\n"), 0644); err != nil {
+		t.Fatalf("Failed to write template file: %v", err)
+	}
+	outputDir := t.TempDir()
+
+	exec, err := New(EngineLibrary, "", false)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := exec.CheckBoilerplateAvailable(); err != nil {
+		t.Errorf("CheckBoilerplateAvailable() error = %v", err)
+	}
+
+	args := []string{
+		"--template-url", templateDir,
+		"--output-folder", outputDir,
+		"--non-interactive",
+		"--var", "Name=World",
+	}
+	if err := exec.Execute(context.Background(), args, "hello"); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "hello.txt"))
+	if err != nil {
+		t.Fatalf("Failed to read rendered file: %v", err)
+	}
+	if string(data) != "Hello, World!\n" {
+		t.Errorf("Expected rendered file %q, got %q", "Hello, World!\n", string(data))
+	}
+}
+
+func TestLibraryExecutor_ExecuteCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	exec := NewLibraryExecutor()
+	if err := exec.Execute(ctx, nil, "hello"); err == nil {
+		t.Error("Expected an error for a cancelled context")
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package executor
+
+import "os/exec"
+
+// setProcessGroup leaves cmd unchanged; cancelling its context kills only
+// the boilerplate process itself.
+func setProcessGroup(cmd *exec.Cmd) (release func()) {
+	return func() {}
+}
@@ -0,0 +1,33 @@
+//go:build unix
+
+package executor
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// setProcessGroup starts cmd in a process group of its own, so cancelling its
+// context terminates boilerplate together with the git or shell processes it
+// started: first with SIGTERM, then with SIGKILL if the group is still
+// running after killGracePeriod. The returned function must be called once
+// the command has exited.
+func setProcessGroup(cmd *exec.Cmd) (release func()) {
+	var timer *time.Timer
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		pgid := -cmd.Process.Pid
+		timer = time.AfterFunc(killGracePeriod, func() {
+			_ = syscall.Kill(pgid, syscall.SIGKILL)
+		})
+		return syscall.Kill(pgid, syscall.SIGTERM)
+	}
+
+	return func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package executor
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ReportFormat is the format of a machine-readable run report.
+type ReportFormat string
+
+const (
+	ReportJSON  ReportFormat = "json"
+	ReportJUnit ReportFormat = "junit"
+)
+
+// Report is a report file to write after a run.
+type Report struct {
+	Format ReportFormat
+	Path   string
+}
+
+// ParseReport parses a report specification of the form format=path, for
+// example "junit=reports/boilerplate.xml".
+func ParseReport(spec string) (Report, error) {
+	format, path, ok := strings.Cut(spec, "=")
+	if !ok || path == "" {
+		return Report{}, fmt.Errorf("invalid report %q (expected format=path)", spec)
+	}
+
+	switch f := ReportFormat(format); f {
+	case ReportJSON, ReportJUnit:
+		return Report{Format: f, Path: path}, nil
+	}
+	return Report{}, fmt.Errorf("invalid report format %q (expected %q or %q)", format, ReportJSON, ReportJUnit)
+}
+
+// Write writes the report for summary to the report's path.
+func (r Report) Write(summary *ExecutionSummary) error {
+	file, err := os.Create(r.Path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s report: %w", r.Format, err)
+	}
+
+	switch r.Format {
+	case ReportJUnit:
+		err = WriteJUnitReport(file, summary)
+	default:
+		err = WriteJSONReport(file, summary)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s report %s: %w", r.Format, r.Path, err)
+	}
+	return nil
+}
+
+type jsonReport struct {
+	Succeeded       int                  `json:"succeeded"`
+	Failed          int                  `json:"failed"`
+	Skipped         int                  `json:"skipped"`
+	Interrupted     int                  `json:"interrupted"`
+	UpToDate        int                  `json:"up_to_date"`
+	DurationSeconds float64              `json:"duration_seconds"`
+	Templates       []jsonTemplateResult `json:"templates"`
+}
+
+type jsonTemplateResult struct {
+	Name            string          `json:"name"`
+	Status          ResultStatus    `json:"status"`
+	AllowFailure    bool            `json:"allow_failure,omitempty"`
+	StartTime       time.Time       `json:"start_time"`
+	EndTime         time.Time       `json:"end_time"`
+	DurationSeconds float64         `json:"duration_seconds"`
+	Args            []string        `json:"args,omitempty"`
+	ExitCode        int             `json:"exit_code"`
+	Error           string          `json:"error,omitempty"`
+	Stderr          string          `json:"stderr,omitempty"`
+	Attempts        []jsonAttempt   `json:"attempts,omitempty"`
+	Collisions      []jsonCollision `json:"collisions,omitempty"`
+}
+
+type jsonCollision struct {
+	File  string `json:"file"`
+	Owner string `json:"owner"`
+	Kept  string `json:"kept"`
+}
+
+type jsonAttempt struct {
+	StartTime       time.Time `json:"start_time"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	ExitCode        int       `json:"exit_code"`
+	TimedOut        bool      `json:"timed_out,omitempty"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// WriteJSONReport writes the results of a run as JSON.
+func WriteJSONReport(w io.Writer, summary *ExecutionSummary) error {
+	report := jsonReport{
+		Succeeded:       summary.SuccessCount,
+		Failed:          summary.FailureCount,
+		Skipped:         summary.SkippedCount,
+		Interrupted:     summary.InterruptedCount,
+		UpToDate:        summary.UpToDateCount,
+		DurationSeconds: summary.TotalDuration.Seconds(),
+		Templates:       make([]jsonTemplateResult, 0, len(summary.Results)),
+	}
+
+	for _, result := range summary.Results {
+		entry := jsonTemplateResult{
+			Name:            result.TemplateName,
+			Status:          result.Status,
+			AllowFailure:    result.AllowFailure,
+			StartTime:       result.StartTime,
+			EndTime:         result.EndTime,
+			DurationSeconds: result.Duration.Seconds(),
+			Args:            result.Args,
+			ExitCode:        result.ExitCode,
+			Stderr:          result.Stderr,
+		}
+		if result.Error != nil {
+			entry.Error = result.Error.Error()
+		}
+		for _, attempt := range result.Attempts {
+			entry.Attempts = append(entry.Attempts, jsonAttempt{
+				StartTime:       attempt.StartTime,
+				DurationSeconds: attempt.Duration.Seconds(),
+				ExitCode:        attempt.ExitCode,
+				TimedOut:        attempt.TimedOut,
+				Error:           errorMessage(attempt.Error),
+			})
+		}
+		for _, collision := range result.Collisions {
+			entry.Collisions = append(entry.Collisions, jsonCollision{
+				File:  collision.File,
+				Owner: collision.Owner,
+				Kept:  collision.Kept,
+			})
+		}
+		report.Templates = append(report.Templates, entry)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnitReport writes the results of a run as JUnit XML, with one test
+// case per template. Failures of templates that are allowed to fail are
+// reported as failures of type "allowed-failure", and interrupted templates
+// as failures of type "interrupted".
+func WriteJUnitReport(w io.Writer, summary *ExecutionSummary) error {
+	const name = "boilerplate-compose"
+
+	suite := junitTestSuite{
+		Name:     name,
+		Tests:    len(summary.Results),
+		Failures: summary.FailureCount + summary.InterruptedCount,
+		Skipped:  summary.SkippedCount,
+		Time:     junitSeconds(summary.TotalDuration),
+	}
+	if len(summary.Results) > 0 {
+		suite.Timestamp = summary.Results[0].StartTime.Format("2006-01-02T15:04:05")
+	}
+
+	for _, result := range summary.Results {
+		testCase := junitTestCase{
+			Name:      result.TemplateName,
+			ClassName: name,
+			Time:      junitSeconds(result.Duration),
+		}
+		if len(result.Args) > 0 {
+			testCase.SystemOut = "boilerplate " + strings.Join(result.Args, " ")
+		}
+
+		switch result.Status {
+		case StatusFailed:
+			failureType := fmt.Sprintf("exit code %d", result.ExitCode)
+			if result.AllowFailure {
+				failureType = "allowed-failure"
+			}
+			testCase.Failure = &junitMessage{
+				Message: errorMessage(result.Error),
+				Type:    failureType,
+				Text:    result.Stderr,
+			}
+		case StatusInterrupted:
+			testCase.Failure = &junitMessage{
+				Message: errorMessage(result.Error),
+				Type:    "interrupted",
+				Text:    result.Stderr,
+			}
+		case StatusSkipped:
+			testCase.Skipped = &junitMessage{Message: errorMessage(result.Error)}
+		}
+
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	report := junitTestSuites{
+		Name:     name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,139 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func reportSummary() *ExecutionSummary {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	summary := NewExecutionSummary()
+	summary.AddResult(ExecutionResult{
+		TemplateName: "app",
+		Success:      true,
+		Args:         []string{"--template-url", "./app"},
+		StartTime:    start,
+		EndTime:      start.Add(1500 * time.Millisecond),
+		Duration:     1500 * time.Millisecond,
+	})
+	summary.AddResult(ExecutionResult{
+		TemplateName: "docs",
+		Error:        errors.New("boilerplate command failed for template 'docs': exit status 3"),
+		Args:         []string{"--template-url", "./docs"},
+		ExitCode:     3,
+		Stderr:       "template not found",
+		StartTime:    start,
+		EndTime:      start.Add(250 * time.Millisecond),
+		Duration:     250 * time.Millisecond,
+	})
+	summary.AddResult(ExecutionResult{
+		TemplateName: "site",
+		Status:       StatusSkipped,
+		Error:        errors.New("dependency 'docs' did not complete"),
+	})
+	summary.TotalDuration = 2 * time.Second
+	return summary
+}
+
+func TestParseReport(t *testing.T) {
+	report, err := ParseReport("junit=out/report.xml")
+	if err != nil {
+		t.Fatalf("ParseReport() error = %v", err)
+	}
+	if report.Format != ReportJUnit || report.Path != "out/report.xml" {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	for _, spec := range []string{"junit", "json=", "html=report.html"} {
+		if _, err := ParseReport(spec); err == nil {
+			t.Errorf("ParseReport(%q) expected an error", spec)
+		}
+	}
+}
+
+func TestWriteJSONReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSONReport(&buf, reportSummary()); err != nil {
+		t.Fatalf("WriteJSONReport() error = %v", err)
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+
+	if report.Succeeded != 1 || report.Failed != 1 || report.Skipped != 1 || report.DurationSeconds != 2 {
+		t.Errorf("unexpected counts %+v", report)
+	}
+	if len(report.Templates) != 3 {
+		t.Fatalf("expected 3 templates, got %d", len(report.Templates))
+	}
+
+	docs := report.Templates[1]
+	if docs.Name != "docs" || docs.Status != StatusFailed || docs.ExitCode != 3 || docs.Stderr != "template not found" {
+		t.Errorf("unexpected failed entry %+v", docs)
+	}
+	if docs.DurationSeconds != 0.25 || len(docs.Args) != 2 {
+		t.Errorf("unexpected timing or args %+v", docs)
+	}
+	if report.Templates[2].Status != StatusSkipped || report.Templates[2].Error != "dependency 'docs' did not complete" {
+		t.Errorf("unexpected skipped entry %+v", report.Templates[2])
+	}
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJUnitReport(&buf, reportSummary()); err != nil {
+		t.Fatalf("WriteJUnitReport() error = %v", err)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+
+	if report.Tests != 3 || report.Failures != 1 || report.Skipped != 1 || len(report.Suites) != 1 {
+		t.Fatalf("unexpected totals %+v", report)
+	}
+
+	cases := report.Suites[0].Cases
+	if cases[0].Name != "app" || cases[0].Time != "1.500" || cases[0].Failure != nil || cases[0].Skipped != nil {
+		t.Errorf("unexpected passing case %+v", cases[0])
+	}
+	if cases[1].Failure == nil || cases[1].Failure.Type != "exit code 3" || cases[1].Failure.Text != "template not found" {
+		t.Errorf("unexpected failed case %+v", cases[1])
+	}
+	if cases[2].Skipped == nil || cases[2].Skipped.Message != "dependency 'docs' did not complete" {
+		t.Errorf("unexpected skipped case %+v", cases[2])
+	}
+	if !strings.HasPrefix(buf.String(), "<?xml") {
+		t.Error("expected an XML header")
+	}
+}
+
+func TestReportWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := (Report{Format: ReportJSON, Path: path}).Write(reportSummary()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !json.Valid(data) {
+		t.Errorf("expected valid JSON, got:\n%s", data)
+	}
+
+	missingDir := Report{Format: ReportJUnit, Path: filepath.Join(t.TempDir(), "missing", "report.xml")}
+	if err := missingDir.Write(reportSummary()); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
@@ -5,13 +5,59 @@ import (
 	"time"
 )
 
+// ResultStatus describes the outcome of a template.
+type ResultStatus string
+
+const (
+	StatusSucceeded ResultStatus = "succeeded"
+	StatusFailed    ResultStatus = "failed"
+	// StatusSkipped marks a template that was not run, for example because
+	// a template it depends on failed. Error holds the reason.
+	StatusSkipped ResultStatus = "skipped"
+	// StatusInterrupted marks a template that was stopped while running
+	// because the run was cancelled, for example with Ctrl-C.
+	StatusInterrupted ResultStatus = "interrupted"
+	// StatusUpToDate marks a template that was not run because its inputs
+	// and output folder are unchanged since it last completed. It counts as
+	// completed.
+	StatusUpToDate ResultStatus = "up-to-date"
+)
+
 type ExecutionResult struct {
 	TemplateName string
 	Success      bool
+	// Status is derived from Success when left empty.
+	Status ResultStatus
+	// AllowFailure is set for templates whose failure does not fail the run.
+	AllowFailure bool
 	Error        error
 	Duration     time.Duration
 	StartTime    time.Time
 	EndTime      time.Time
+	// Args are the arguments boilerplate was run with.
+	Args []string
+	// ExitCode is the exit code of boilerplate: 0 on success, -1 when the
+	// template failed without boilerplate reporting an exit code.
+	ExitCode int
+	// Stderr holds the last lines boilerplate wrote to stderr on failure.
+	Stderr string
+	// Attempts records every run of the template when it was retried.
+	Attempts []Attempt
+	// Collisions lists the files this template wrote that another template
+	// had already written in the same run.
+	Collisions []Collision
+}
+
+// Collision is a file written by two templates in the same run.
+type Collision struct {
+	// File is the absolute path of the file.
+	File string
+	// Template is the template that wrote the file last.
+	Template string
+	// Owner is the template that wrote the file first.
+	Owner string
+	// Kept is the template whose version of the file was kept.
+	Kept string
 }
 
 type ExecutionSummary struct {
@@ -19,6 +65,18 @@ type ExecutionSummary struct {
 	TotalDuration time.Duration
 	SuccessCount  int
 	FailureCount  int
+	SkippedCount  int
+	// InterruptedCount is the number of templates stopped while running.
+	InterruptedCount int
+	// UpToDateCount is the number of templates not run because they were
+	// up to date.
+	UpToDateCount int
+	// AllowedFailureCount is the number of failures, included in
+	// FailureCount, of templates that are allowed to fail.
+	AllowedFailureCount int
+	// CollisionCount is the number of files written by more than one
+	// template.
+	CollisionCount int
 }
 
 func NewExecutionSummary() *ExecutionSummary {
@@ -28,37 +86,127 @@ func NewExecutionSummary() *ExecutionSummary {
 }
 
 func (s *ExecutionSummary) AddResult(result ExecutionResult) {
+	if result.Status == "" {
+		result.Status = StatusFailed
+		if result.Success {
+			result.Status = StatusSucceeded
+		}
+	}
+
 	s.Results = append(s.Results, result)
-	if result.Success {
+	switch result.Status {
+	case StatusSucceeded:
 		s.SuccessCount++
-	} else {
+	case StatusSkipped:
+		s.SkippedCount++
+	case StatusInterrupted:
+		s.InterruptedCount++
+	case StatusUpToDate:
+		s.UpToDateCount++
+	default:
 		s.FailureCount++
+		if result.AllowFailure {
+			s.AllowedFailureCount++
+		}
 	}
+	s.CollisionCount += len(result.Collisions)
 	s.TotalDuration += result.Duration
 }
 
+// BlockingFailureCount returns the number of failed templates that are not
+// allowed to fail.
+func (s *ExecutionSummary) BlockingFailureCount() int {
+	return s.FailureCount - s.AllowedFailureCount
+}
+
 func (s *ExecutionSummary) Print() {
 	fmt.Printf("\n=== Execution Summary ===\n")
 	fmt.Printf("Total templates: %d\n", len(s.Results))
 	fmt.Printf("Successful: %d\n", s.SuccessCount)
-	fmt.Printf("Failed: %d\n", s.FailureCount)
+	if s.AllowedFailureCount > 0 {
+		fmt.Printf("Failed: %d (%d allowed to fail)\n", s.FailureCount, s.AllowedFailureCount)
+	} else {
+		fmt.Printf("Failed: %d\n", s.FailureCount)
+	}
+	if s.UpToDateCount > 0 {
+		fmt.Printf("Up to date: %d\n", s.UpToDateCount)
+	}
+	if s.SkippedCount > 0 {
+		fmt.Printf("Skipped: %d\n", s.SkippedCount)
+	}
+	if s.InterruptedCount > 0 {
+		fmt.Printf("Interrupted: %d\n", s.InterruptedCount)
+	}
 	fmt.Printf("Total duration: %v\n", s.TotalDuration)
 
 	if s.FailureCount > 0 {
 		fmt.Printf("\nFailed templates:\n")
 		for _, result := range s.Results {
-			if !result.Success {
+			if result.Status != StatusFailed {
+				continue
+			}
+			if result.AllowFailure {
+				fmt.Printf("  - %s (allowed to fail): %v\n", result.TemplateName, result.Error)
+			} else {
 				fmt.Printf("  - %s: %v\n", result.TemplateName, result.Error)
 			}
 		}
 	}
 
+	if s.SkippedCount > 0 {
+		fmt.Printf("\nSkipped templates:\n")
+		for _, result := range s.Results {
+			if result.Status == StatusSkipped {
+				fmt.Printf("  - %s: %v\n", result.TemplateName, result.Error)
+			}
+		}
+	}
+
+	if s.InterruptedCount > 0 {
+		fmt.Printf("\nInterrupted templates:\n")
+		for _, result := range s.Results {
+			if result.Status == StatusInterrupted {
+				fmt.Printf("  - %s: %v\n", result.TemplateName, result.Error)
+			}
+		}
+	}
+
+	if s.CollisionCount > 0 {
+		fmt.Printf("\nFile collisions:\n")
+		for _, result := range s.Results {
+			for _, c := range result.Collisions {
+				fmt.Printf("  - %s: written by '%s', then '%s' (kept '%s')\n", c.File, c.Owner, c.Template, c.Kept)
+			}
+		}
+	}
+
+	// With failures, skips or interruptions, also list what did succeed
+	if s.SuccessCount > 0 && s.FailureCount+s.SkippedCount+s.InterruptedCount > 0 {
+		fmt.Printf("\nSucceeded templates:\n")
+		for _, result := range s.Results {
+			if result.Status == StatusSucceeded {
+				fmt.Printf("  - %s\n", result.TemplateName)
+			}
+		}
+	}
+
 	fmt.Printf("\nTemplate execution times:\n")
 	for _, result := range s.Results {
 		status := "✓"
-		if !result.Success {
+		switch result.Status {
+		case StatusFailed:
 			status = "✗"
+		case StatusSkipped:
+			status = "-"
+		case StatusInterrupted:
+			status = "!"
+		case StatusUpToDate:
+			status = "="
+		}
+		if len(result.Attempts) > 1 {
+			fmt.Printf("  %s %s: %v (%d attempts)\n", status, result.TemplateName, result.Duration, len(result.Attempts))
+		} else {
+			fmt.Printf("  %s %s: %v\n", status, result.TemplateName, result.Duration)
 		}
-		fmt.Printf("  %s %s: %v\n", status, result.TemplateName, result.Duration)
 	}
 }
\ No newline at end of file
@@ -101,4 +101,52 @@ func TestExecutionSummary_AddResult(t *testing.T) {
 	if summary.TotalDuration != expectedDuration {
 		t.Errorf("expected TotalDuration %v, got %v", expectedDuration, summary.TotalDuration)
 	}
-}
\ No newline at end of file
+}
+func TestExecutionSummary_AddResult_Skipped(t *testing.T) {
+	summary := NewExecutionSummary()
+	summary.AddResult(ExecutionResult{TemplateName: "ok", Success: true})
+	summary.AddResult(ExecutionResult{TemplateName: "broken", Success: false, Error: errors.New("boom")})
+	summary.AddResult(ExecutionResult{
+		TemplateName: "dependent",
+		Status:       StatusSkipped,
+		Error:        errors.New("dependency 'broken' did not complete"),
+	})
+
+	if summary.SuccessCount != 1 || summary.FailureCount != 1 || summary.SkippedCount != 1 {
+		t.Errorf("expected 1 succeeded, 1 failed, 1 skipped; got %d, %d, %d",
+			summary.SuccessCount, summary.FailureCount, summary.SkippedCount)
+	}
+
+	if summary.Results[0].Status != StatusSucceeded || summary.Results[1].Status != StatusFailed {
+		t.Errorf("expected status to be derived from Success, got %q and %q",
+			summary.Results[0].Status, summary.Results[1].Status)
+	}
+}
+
+func TestExecutionSummary_AddResult_UpToDate(t *testing.T) {
+	summary := NewExecutionSummary()
+	summary.AddResult(ExecutionResult{TemplateName: "changed", Success: true})
+	summary.AddResult(ExecutionResult{TemplateName: "unchanged", Success: true, Status: StatusUpToDate})
+
+	if summary.SuccessCount != 1 || summary.UpToDateCount != 1 || summary.FailureCount != 0 {
+		t.Errorf("expected 1 succeeded, 1 up to date, 0 failed; got %d, %d, %d",
+			summary.SuccessCount, summary.UpToDateCount, summary.FailureCount)
+	}
+}
+
+func TestExecutionSummary_AllowedFailures(t *testing.T) {
+	summary := NewExecutionSummary()
+	summary.AddResult(ExecutionResult{TemplateName: "ok", Success: true})
+	summary.AddResult(ExecutionResult{TemplateName: "broken", Error: errors.New("exit status 1")})
+	summary.AddResult(ExecutionResult{TemplateName: "optional", AllowFailure: true, Error: errors.New("exit status 1")})
+
+	if summary.FailureCount != 2 {
+		t.Errorf("expected FailureCount 2, got %d", summary.FailureCount)
+	}
+	if summary.AllowedFailureCount != 1 {
+		t.Errorf("expected AllowedFailureCount 1, got %d", summary.AllowedFailureCount)
+	}
+	if got := summary.BlockingFailureCount(); got != 1 {
+		t.Errorf("expected BlockingFailureCount 1, got %d", got)
+	}
+}
@@ -0,0 +1,123 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"time"
+)
+
+// RetryPolicy decides whether a failed template is run again.
+type RetryPolicy struct {
+	// Attempts is the total number of runs, including the first. Values
+	// below 1 mean a single run.
+	Attempts int
+	// Backoff is the delay before the first retry, doubled for every
+	// further retry.
+	Backoff time.Duration
+	// OnExitCodes and OnStderrMatch restrict retries to failures with one
+	// of the exit codes or with stderr output matching one of the patterns.
+	// When both are empty every failure is retried.
+	OnExitCodes   []int
+	OnStderrMatch []*regexp.Regexp
+}
+
+// Attempt records one run of a template.
+type Attempt struct {
+	StartTime time.Time
+	Duration  time.Duration
+	// ExitCode is the exit code of boilerplate, 0 on success or -1 when it
+	// is not known.
+	ExitCode int
+	// TimedOut is set when the attempt was stopped by its timeout.
+	TimedOut bool
+	Error    error
+}
+
+// retryable reports whether a failed attempt should be retried.
+func (p RetryPolicy) retryable(err error, timedOut bool) bool {
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) && !timedOut {
+		// Not a failure of boilerplate itself, such as a missing binary
+		return false
+	}
+	if len(p.OnExitCodes) == 0 && len(p.OnStderrMatch) == 0 {
+		return true
+	}
+	if cmdErr == nil {
+		return false
+	}
+
+	for _, code := range p.OnExitCodes {
+		if cmdErr.ExitCode == code {
+			return true
+		}
+	}
+	for _, pattern := range p.OnStderrMatch {
+		if pattern.MatchString(cmdErr.Stderr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExecuteWithRetry runs a template with exec until it succeeds, fails in a
+// way the policy does not retry, or runs out of attempts. When timeout is
+// positive it limits every attempt separately. It returns every attempt and
+// the error of the last one. Cancelling ctx stops further attempts.
+func ExecuteWithRetry(ctx context.Context, exec Executor, policy RetryPolicy, timeout time.Duration, args []string, templateName string) ([]Attempt, error) {
+	maxAttempts := policy.Attempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var attempts []Attempt
+	backoff := policy.Backoff
+
+	for {
+		attempt := runAttempt(ctx, exec, timeout, args, templateName)
+		attempts = append(attempts, attempt)
+
+		if attempt.Error == nil || ctx.Err() != nil || len(attempts) >= maxAttempts ||
+			!policy.retryable(attempt.Error, attempt.TimedOut) {
+			return attempts, attempt.Error
+		}
+
+		log.Printf("Template '%s' failed (attempt %d of %d), retrying in %v: %v",
+			templateName, len(attempts), maxAttempts, backoff, attempt.Error)
+
+		select {
+		case <-ctx.Done():
+			return attempts, attempt.Error
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func runAttempt(ctx context.Context, exec Executor, timeout time.Duration, args []string, templateName string) Attempt {
+	attemptCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	attempt := Attempt{StartTime: time.Now()}
+	attempt.Error = exec.Execute(attemptCtx, args, templateName)
+	attempt.Duration = time.Since(attempt.StartTime)
+
+	var cmdErr *CommandError
+	switch {
+	case attempt.Error == nil:
+	case errors.As(attempt.Error, &cmdErr):
+		attempt.ExitCode = cmdErr.ExitCode
+	default:
+		attempt.ExitCode = -1
+	}
+	attempt.TimedOut = attempt.Error != nil && ctx.Err() == nil &&
+		errors.Is(attemptCtx.Err(), context.DeadlineExceeded)
+
+	return attempt
+}
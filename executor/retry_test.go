@@ -0,0 +1,123 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// scriptedExecutor returns the given errors in turn, then succeeds
+type scriptedExecutor struct {
+	errs  []error
+	calls int
+}
+
+func (e *scriptedExecutor) Execute(ctx context.Context, args []string, templateName string) error {
+	e.calls++
+	if e.calls <= len(e.errs) {
+		return e.errs[e.calls-1]
+	}
+	return nil
+}
+
+func (e *scriptedExecutor) CheckBoilerplateAvailable() error {
+	return nil
+}
+
+func commandError(exitCode int, stderr string) error {
+	return &CommandError{TemplateName: "t", ExitCode: exitCode, Stderr: stderr, Err: errors.New("exit status")}
+}
+
+func TestExecuteWithRetry(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		errs     []error
+		attempts int
+		success  bool
+	}{
+		{"no retry by default", RetryPolicy{}, []error{commandError(1, "")}, 1, false},
+		{"retries any failure", RetryPolicy{Attempts: 3}, []error{commandError(1, ""), commandError(1, "")}, 3, true},
+		{"gives up after attempts", RetryPolicy{Attempts: 2}, []error{commandError(1, ""), commandError(1, ""), commandError(1, "")}, 2, false},
+		{"retries listed exit code", RetryPolicy{Attempts: 3, OnExitCodes: []int{128}}, []error{commandError(128, "")}, 2, true},
+		{"does not retry other exit codes", RetryPolicy{Attempts: 3, OnExitCodes: []int{128}}, []error{commandError(1, "")}, 1, false},
+		{
+			"retries matching stderr",
+			RetryPolicy{Attempts: 3, OnStderrMatch: []*regexp.Regexp{regexp.MustCompile(`Could not resolve host`)}},
+			[]error{commandError(1, "fatal: Could not resolve host: github.com")},
+			2, true,
+		},
+		{
+			"does not retry other stderr",
+			RetryPolicy{Attempts: 3, OnStderrMatch: []*regexp.Regexp{regexp.MustCompile(`Could not resolve host`)}},
+			[]error{commandError(1, "template error: missing key")},
+			1, false,
+		},
+		{"does not retry non-boilerplate errors", RetryPolicy{Attempts: 3}, []error{errors.New("failed to start")}, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &scriptedExecutor{errs: tt.errs}
+			attempts, err := ExecuteWithRetry(context.Background(), exec, tt.policy, 0, nil, "t")
+
+			if len(attempts) != tt.attempts || exec.calls != tt.attempts {
+				t.Errorf("expected %d attempts, got %d (%d calls)", tt.attempts, len(attempts), exec.calls)
+			}
+			if (err == nil) != tt.success {
+				t.Errorf("unexpected error %v", err)
+			}
+			if last := attempts[len(attempts)-1]; last.Error != err {
+				t.Errorf("last attempt error %v does not match %v", last.Error, err)
+			}
+		})
+	}
+}
+
+func TestExecuteWithRetry_Backoff(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	exec := &scriptedExecutor{errs: []error{commandError(1, ""), commandError(1, "")}}
+	policy := RetryPolicy{Attempts: 3, Backoff: 20 * time.Millisecond}
+
+	attempts, err := ExecuteWithRetry(context.Background(), exec, policy, 0, nil, "t")
+	if err != nil {
+		t.Fatalf("ExecuteWithRetry() error = %v", err)
+	}
+
+	// 20ms before the second attempt, 40ms before the third
+	if gap := attempts[2].StartTime.Sub(attempts[0].StartTime); gap < 60*time.Millisecond {
+		t.Errorf("expected at least 60ms of backoff, got %v", gap)
+	}
+	if attempts[0].ExitCode != 1 || attempts[2].ExitCode != 0 {
+		t.Errorf("unexpected exit codes %d, %d", attempts[0].ExitCode, attempts[2].ExitCode)
+	}
+}
+
+func TestExecuteWithRetry_StopsWhenCancelled(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	exec := &scriptedExecutor{errs: []error{commandError(1, ""), commandError(1, "")}}
+	policy := RetryPolicy{Attempts: 3, Backoff: time.Hour}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	attempts, err := ExecuteWithRetry(ctx, exec, policy, 0, nil, "t")
+	if err == nil || len(attempts) != 1 {
+		t.Errorf("expected to stop after the first attempt, got %d attempts, error %v", len(attempts), err)
+	}
+}
@@ -0,0 +1,177 @@
+// Package lock pins the sources of templates so a compose file renders the
+// same output over time. Git sources are pinned to a commit and local
+// templates are recorded with a hash of their content.
+package lock
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"boilerplate-compose/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Extension replaces the extension of the compose file to name its lock
+// file, so boilerplate-compose.yaml is locked in boilerplate-compose.lock.
+const Extension = ".lock"
+
+// fileVersion is the version of the lock file format.
+const fileVersion = 1
+
+const header = "# Generated by boilerplate-compose lock. Do not edit by hand; run\n# 'boilerplate-compose update' to move templates to newer commits.\n"
+
+// File is the content of a lock file.
+type File struct {
+	Version   int              `yaml:"version"`
+	Templates map[string]Entry `yaml:"templates"`
+}
+
+// Entry records the source a template was locked to.
+type Entry struct {
+	// TemplateURL is the template URL as written in the compose file,
+	// after interpolation.
+	TemplateURL string `yaml:"template-url"`
+	// Commit is the commit a git source is pinned to.
+	Commit string `yaml:"commit,omitempty"`
+	// Hash is the content hash of a local template.
+	Hash string `yaml:"hash,omitempty"`
+}
+
+// PathFor returns the path of the lock file that belongs to a compose file.
+// It is kept next to the compose file and named after it, so compose files
+// that share a directory each have their own lock file.
+func PathFor(configPath string) string {
+	return strings.TrimSuffix(configPath, filepath.Ext(configPath)) + Extension
+}
+
+// Load reads a lock file. A missing file is reported with an error that
+// matches fs.ErrNotExist.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock file: %w", err)
+	}
+
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse lock file %s: %w", path, err)
+	}
+	if file.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported lock file version %d in %s", file.Version, path)
+	}
+	if file.Templates == nil {
+		file.Templates = make(map[string]Entry)
+	}
+	return &file, nil
+}
+
+// New returns an empty lock file.
+func New() *File {
+	return &File{Version: fileVersion, Templates: make(map[string]Entry)}
+}
+
+// Save writes the lock file. Templates are written in alphabetical order so
+// the file diffs cleanly.
+func (f *File) Save(path string) error {
+	var b bytes.Buffer
+	b.WriteString(header)
+
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(f); err != nil {
+		return fmt.Errorf("failed to encode lock file: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode lock file: %w", err)
+	}
+
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return nil
+}
+
+// Problem is a template whose lock entry is missing or out of date.
+type Problem struct {
+	Template string
+	Reason   string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("template '%s': %s", p.Template, p.Reason)
+}
+
+// Check compares the lock file with the templates of a compose file and
+// returns every template that is not locked or whose lock entry is stale.
+// Git sources are not fetched; only local templates are read to compare
+// their content.
+func (f *File) Check(cfg *config.ComposeConfig) []Problem {
+	var problems []Problem
+	for _, name := range cfg.TemplateNames() {
+		if reason := f.staleReason(name, cfg.Templates[name]); reason != "" {
+			problems = append(problems, Problem{Template: name, Reason: reason})
+		}
+	}
+	return problems
+}
+
+// staleReason explains why the lock entry of a template is out of date, or
+// returns an empty string when it is current.
+func (f *File) staleReason(name string, template config.Template) string {
+	entry, ok := f.Templates[name]
+	if !ok {
+		return "not in the lock file"
+	}
+	if entry.TemplateURL != template.TemplateURL {
+		return fmt.Sprintf("template-url changed from %s to %s", entry.TemplateURL, template.TemplateURL)
+	}
+
+	if ParseSource(template.TemplateURL).Kind == SourceLocal {
+		hash, err := HashDir(template.TemplateURL)
+		if err != nil {
+			return err.Error()
+		}
+		if hash != entry.Hash {
+			return "local template content changed"
+		}
+	}
+	return ""
+}
+
+// Apply pins every git template of the compose file whose lock entry is
+// current to its locked commit.
+func (f *File) Apply(cfg *config.ComposeConfig) {
+	for name, template := range cfg.Templates {
+		entry, ok := f.Templates[name]
+		if !ok || entry.Commit == "" || entry.TemplateURL != template.TemplateURL {
+			continue
+		}
+		template.TemplateURL = PinnedURL(template.TemplateURL, entry.Commit)
+		cfg.Templates[name] = template
+	}
+}
+
+// Prune removes the entries of templates that are not in the compose file
+// and returns their names.
+func (f *File) Prune(cfg *config.ComposeConfig) []string {
+	var removed []string
+	for name := range f.Templates {
+		if _, ok := cfg.Templates[name]; !ok {
+			removed = append(removed, name)
+			delete(f.Templates, name)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// IsCurrent reports whether the lock entry of a template matches the
+// compose file.
+func (f *File) IsCurrent(name string, template config.Template) bool {
+	return f.staleReason(name, template) == ""
+}
@@ -0,0 +1,249 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"boilerplate-compose/config"
+)
+
+// gitRepo creates a repository with a commit on main and returns its path.
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "boilerplate.yml"), []byte("variables: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "init", "-q", "-b", "main")
+	git(t, dir, "config", "user.name", "test")
+	git(t, dir, "config", "user.email", "test@example.com")
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-q", "-m", "initial")
+	return dir
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestResolve(t *testing.T) {
+	repo := gitRepo(t)
+	head := git(t, repo, "rev-parse", "HEAD")
+	git(t, repo, "tag", "-a", "v1", "-m", "v1")
+
+	local := t.TempDir()
+	if err := os.WriteFile(filepath.Join(local, "boilerplate.yml"), []byte("variables: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		templateURL string
+		expected    Entry
+	}{
+		{"branch", "git::file://" + repo + "//?ref=main", Entry{Commit: head}},
+		{"annotated tag", "git::file://" + repo + "?ref=v1", Entry{Commit: head}},
+		{"default branch", "git::file://" + repo, Entry{Commit: head}},
+		{"commit", "git::file://" + repo + "?ref=" + head, Entry{Commit: head}},
+		{"other", "https://example.com/templates.zip", Entry{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := Resolve(context.Background(), config.Template{TemplateURL: tt.templateURL})
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			tt.expected.TemplateURL = tt.templateURL
+			if entry != tt.expected {
+				t.Errorf("Resolve() = %+v, want %+v", entry, tt.expected)
+			}
+		})
+	}
+
+	t.Run("local", func(t *testing.T) {
+		entry, err := Resolve(context.Background(), config.Template{TemplateURL: local})
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if entry.Commit != "" || !strings.HasPrefix(entry.Hash, "sha256:") {
+			t.Errorf("expected a content hash, got %+v", entry)
+		}
+	})
+
+	t.Run("missing ref", func(t *testing.T) {
+		_, err := Resolve(context.Background(), config.Template{TemplateURL: "git::file://" + repo + "?ref=nope"})
+		if err == nil || !strings.Contains(err.Error(), "ref nope not found") {
+			t.Errorf("expected a missing ref error, got %v", err)
+		}
+	})
+}
+
+func TestHashDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash := func() string {
+		t.Helper()
+		h, err := HashDir(dir)
+		if err != nil {
+			t.Fatalf("HashDir() error = %v", err)
+		}
+		return h
+	}
+
+	write("a.txt", "ab")
+	write("sub/b.txt", "c")
+	initial := hash()
+
+	write(".git/HEAD", "ref: refs/heads/main\n")
+	if hash() != initial {
+		t.Error("expected the .git directory to be ignored")
+	}
+
+	// Moving content between files changes the hash
+	write("a.txt", "a")
+	write("sub/b.txt", "bc")
+	if hash() == initial {
+		t.Error("expected the hash to change with the content")
+	}
+
+	if _, err := HashDir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
+
+func TestFile(t *testing.T) {
+	repo := gitRepo(t)
+	head := git(t, repo, "rev-parse", "HEAD")
+	local := t.TempDir()
+	if err := os.WriteFile(filepath.Join(local, "boilerplate.yml"), []byte("variables: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	remoteURL := "git::file://" + repo + "//?ref=main"
+	cfg := &config.ComposeConfig{Templates: map[string]config.Template{
+		"remote": {TemplateURL: remoteURL, OutputFolder: "./remote"},
+		"local":  {TemplateURL: local, OutputFolder: "./local"},
+	}}
+
+	lockFile := New()
+	for name, template := range cfg.Templates {
+		entry, err := Resolve(context.Background(), template)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		lockFile.Templates[name] = entry
+	}
+	lockFile.Templates["removed"] = Entry{TemplateURL: "./removed"}
+
+	t.Run("prune", func(t *testing.T) {
+		removed := lockFile.Prune(cfg)
+		if len(removed) != 1 || removed[0] != "removed" {
+			t.Errorf("Prune() = %v, want [removed]", removed)
+		}
+	})
+
+	t.Run("save and load", func(t *testing.T) {
+		path := PathFor(filepath.Join(t.TempDir(), "boilerplate-compose.yaml"))
+		if err := lockFile.Save(path); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		loaded, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if len(loaded.Templates) != 2 || loaded.Templates["remote"] != lockFile.Templates["remote"] {
+			t.Errorf("Load() = %+v, want %+v", loaded, lockFile)
+		}
+
+		if _, err := Load(filepath.Join(t.TempDir(), "boilerplate-compose.lock")); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected a not exist error for a missing lock file, got %v", err)
+		}
+	})
+
+	t.Run("current", func(t *testing.T) {
+		if problems := lockFile.Check(cfg); len(problems) != 0 {
+			t.Errorf("Check() = %v, want no problems", problems)
+		}
+	})
+
+	t.Run("apply pins git sources", func(t *testing.T) {
+		pinned := &config.ComposeConfig{Templates: map[string]config.Template{}}
+		for name, template := range cfg.Templates {
+			pinned.Templates[name] = template
+		}
+		lockFile.Apply(pinned)
+
+		if got, want := pinned.Templates["remote"].TemplateURL, "git::file://"+repo+"//?ref="+head; got != want {
+			t.Errorf("remote template-url = %q, want %q", got, want)
+		}
+		if got := pinned.Templates["local"].TemplateURL; got != local {
+			t.Errorf("local template-url = %q, want it unchanged", got)
+		}
+	})
+
+	t.Run("stale", func(t *testing.T) {
+		stale := &config.ComposeConfig{Templates: map[string]config.Template{
+			"remote": {TemplateURL: "git::file://" + repo + "//?ref=v2"},
+			"local":  {TemplateURL: local},
+			"added":  {TemplateURL: local},
+		}}
+		if err := os.WriteFile(filepath.Join(local, "boilerplate.yml"), []byte("variables: [changed]\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var reasons []string
+		for _, problem := range lockFile.Check(stale) {
+			reasons = append(reasons, problem.String())
+		}
+		expected := []string{
+			"template 'added': not in the lock file",
+			"template 'local': local template content changed",
+			"template 'remote': template-url changed from " + remoteURL + " to git::file://" + repo + "//?ref=v2",
+		}
+		if strings.Join(reasons, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("Check() =\n%s\nwant\n%s", strings.Join(reasons, "\n"), strings.Join(expected, "\n"))
+		}
+	})
+}
+
+func TestPathFor(t *testing.T) {
+	tests := []struct {
+		configPath string
+		expected   string
+	}{
+		{"boilerplate-compose.yaml", "boilerplate-compose.lock"},
+		{filepath.Join("tests", "complex-test.yml"), filepath.Join("tests", "complex-test.lock")},
+		{filepath.Join("ci", "compose"), filepath.Join("ci", "compose.lock")},
+	}
+
+	for _, tt := range tests {
+		if got := PathFor(tt.configPath); got != tt.expected {
+			t.Errorf("PathFor(%q) = %q, want %q", tt.configPath, got, tt.expected)
+		}
+	}
+}
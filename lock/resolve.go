@@ -0,0 +1,196 @@
+package lock
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"boilerplate-compose/config"
+)
+
+// Resolve works out the lock entry of a template. Git sources are resolved
+// to the commit their ref points at with git ls-remote; local templates are
+// hashed. Sources that cannot be pinned are recorded by URL only.
+func Resolve(ctx context.Context, template config.Template) (Entry, error) {
+	entry := Entry{TemplateURL: template.TemplateURL}
+
+	source := ParseSource(template.TemplateURL)
+	switch source.Kind {
+	case SourceGit:
+		commit, err := ResolveCommit(ctx, source)
+		if err != nil {
+			return entry, err
+		}
+		entry.Commit = commit
+	case SourceLocal:
+		hash, err := HashDir(template.TemplateURL)
+		if err != nil {
+			return entry, err
+		}
+		entry.Hash = hash
+	}
+
+	return entry, nil
+}
+
+// ResolveCommit returns the commit the ref of a git source points at. A ref
+// that is already a full commit SHA is returned without contacting the
+// repository.
+func ResolveCommit(ctx context.Context, source Source) (string, error) {
+	if commitPattern.MatchString(source.Ref) {
+		return source.Ref, nil
+	}
+
+	// Annotated tags are only peeled to their commit when asked for
+	output, err := RunGit(ctx, "", "ls-remote", source.Repository, source.Ref, source.Ref+"^{}")
+	if err != nil {
+		return "", err
+	}
+
+	commit := matchRef(output, source.Ref)
+	if commit == "" {
+		return "", fmt.Errorf("ref %s not found in %s", source.Ref, source.Repository)
+	}
+	return commit, nil
+}
+
+// GitError is returned when a git command fails.
+type GitError struct {
+	Args []string
+	// ExitCode is the exit code of git, or -1 if it was not known.
+	ExitCode int
+	Stderr   string
+	Err      error
+}
+
+func (e *GitError) Error() string {
+	return fmt.Sprintf("git %s failed: %v: %s", strings.Join(e.Args, " "), e.Err, e.Stderr)
+}
+
+func (e *GitError) Unwrap() error {
+	return e.Err
+}
+
+// RunGit runs git with args in dir, or in the current directory when dir is
+// empty, and returns its output. Failures are returned as *GitError.
+func RunGit(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		exitCode := -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		}
+		return "", &GitError{Args: args, ExitCode: exitCode, Stderr: strings.TrimSpace(stderr.String()), Err: err}
+	}
+	return stdout.String(), nil
+}
+
+// matchRef picks the commit of ref from git ls-remote output. Branches win
+// over tags, and an annotated tag resolves to the commit it points at.
+func matchRef(output, ref string) string {
+	refs := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		commit, name, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if ok {
+			refs[name] = commit
+		}
+	}
+
+	candidates := []string{
+		ref,
+		"refs/heads/" + ref,
+		"refs/tags/" + ref + "^{}",
+		"refs/tags/" + ref,
+	}
+	for _, name := range candidates {
+		if commit, ok := refs[name]; ok {
+			return commit
+		}
+	}
+	return ""
+}
+
+// HashDir returns a hash of the files below dir, covering their relative
+// paths and content. The .git directory is left out.
+func HashDir(dir string) (string, error) {
+	return HashDirExcept(dir)
+}
+
+// HashDirExcept is HashDir leaving out the given files as well.
+func HashDirExcept(dir string, except ...string) (string, error) {
+	skip := make(map[string]bool, len(except))
+	for _, path := range except {
+		if abs, err := filepath.Abs(path); err == nil {
+			skip[abs] = true
+		}
+	}
+
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		if len(skip) > 0 {
+			if abs, err := filepath.Abs(path); err == nil && skip[abs] {
+				return nil
+			}
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash template %s: %w", dir, err)
+	}
+	sort.Strings(paths)
+
+	hash := sha256.New()
+	for _, path := range paths {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return "", err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash template %s: %w", dir, err)
+		}
+
+		// Length prefixes keep the boundaries between files unambiguous
+		fmt.Fprintf(hash, "%s\x00%d\x00", filepath.ToSlash(rel), info.Size())
+		if err := hashFile(hash, path); err != nil {
+			return "", fmt.Errorf("failed to hash template %s: %w", dir, err)
+		}
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func hashFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,115 @@
+package lock
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"boilerplate-compose/config"
+)
+
+// SourceKind is the kind of location a template is fetched from.
+type SourceKind string
+
+const (
+	// SourceLocal is a directory on disk, pinned by a hash of its content
+	SourceLocal SourceKind = "local"
+	// SourceGit is a git repository, pinned to a commit
+	SourceGit SourceKind = "git"
+	// SourceOther is a remote source that cannot be pinned, such as an
+	// archive downloaded over HTTP
+	SourceOther SourceKind = "other"
+)
+
+// gitHosts are hosts whose repositories boilerplate fetches with git.
+var gitHosts = []string{"github.com", "gitlab.com", "bitbucket.org"}
+
+// commitPattern matches a full commit SHA.
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Source is a template URL broken into the parts needed to pin it.
+type Source struct {
+	Kind SourceKind
+	// Repository is the URL git is given for a git source.
+	Repository string
+	// Ref is the branch, tag or commit of a git source; HEAD when the
+	// template URL has none.
+	Ref string
+	// Subdir is the directory of the template within a git repository.
+	Subdir string
+}
+
+// ParseSource works out where a template URL is fetched from. Template
+// URLs use the go-getter syntax understood by boilerplate, such as
+// "git@github.com:org/repo.git//templates/app?ref=v1.2.0".
+func ParseSource(templateURL string) Source {
+	if config.IsLocalTemplateURL(templateURL) {
+		return Source{Kind: SourceLocal}
+	}
+
+	address, query, _ := strings.Cut(templateURL, "?")
+	forcedGit := strings.HasPrefix(address, "git::")
+	address = strings.TrimPrefix(address, "git::")
+	if strings.Contains(address, "::") {
+		// Another forced getter, such as s3:: or hg::
+		return Source{Kind: SourceOther}
+	}
+
+	repository, subdir := splitSubdirectory(address)
+	for _, host := range gitHosts {
+		if strings.HasPrefix(repository, host+"/") {
+			repository = "https://" + repository
+		}
+	}
+
+	if !forcedGit && !isGitRepository(repository) {
+		return Source{Kind: SourceOther}
+	}
+
+	ref := "HEAD"
+	if values, err := url.ParseQuery(query); err == nil && values.Get("ref") != "" {
+		ref = values.Get("ref")
+	}
+	return Source{Kind: SourceGit, Repository: repository, Ref: ref, Subdir: subdir}
+}
+
+// splitSubdirectory splits off the "//path" that selects a directory within
+// the source.
+func splitSubdirectory(address string) (string, string) {
+	start := 0
+	if i := strings.Index(address, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	if i := strings.Index(address[start:], "//"); i >= 0 {
+		return address[:start+i], strings.Trim(address[start+i+len("//"):], "/")
+	}
+	return address, ""
+}
+
+func isGitRepository(repository string) bool {
+	if strings.HasPrefix(repository, "git@") || strings.HasPrefix(repository, "ssh://") || strings.HasSuffix(repository, ".git") {
+		return true
+	}
+
+	u, err := url.Parse(repository)
+	if err != nil {
+		return false
+	}
+	for _, host := range gitHosts {
+		if u.Host == host {
+			return true
+		}
+	}
+	return false
+}
+
+// PinnedURL returns the template URL with its ref replaced by commit.
+func PinnedURL(templateURL, commit string) string {
+	address, query, _ := strings.Cut(templateURL, "?")
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		values = url.Values{}
+	}
+	values.Set("ref", commit)
+	return address + "?" + values.Encode()
+}
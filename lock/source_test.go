@@ -0,0 +1,83 @@
+package lock
+
+import "testing"
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		templateURL string
+		expected    Source
+	}{
+		{"./templates/web", Source{Kind: SourceLocal}},
+		{"/abs/templates/web", Source{Kind: SourceLocal}},
+		{
+			"git@github.com:gruntwork-io/boilerplate.git//examples/for-learning-and-testing/docs?ref=main",
+			Source{Kind: SourceGit, Repository: "git@github.com:gruntwork-io/boilerplate.git", Ref: "main", Subdir: "examples/for-learning-and-testing/docs"},
+		},
+		{
+			"github.com/org/templates//web?ref=v1.2.0",
+			Source{Kind: SourceGit, Repository: "https://github.com/org/templates", Ref: "v1.2.0", Subdir: "web"},
+		},
+		{
+			"https://github.com/org/templates//web",
+			Source{Kind: SourceGit, Repository: "https://github.com/org/templates", Ref: "HEAD", Subdir: "web"},
+		},
+		{
+			"git::https://example.com/templates.git//web?ref=main&depth=1",
+			Source{Kind: SourceGit, Repository: "https://example.com/templates.git", Ref: "main", Subdir: "web"},
+		},
+		{
+			"git::file:///srv/templates//web",
+			Source{Kind: SourceGit, Repository: "file:///srv/templates", Ref: "HEAD", Subdir: "web"},
+		},
+		{
+			"ssh://git@example.com/org/templates//web?ref=main",
+			Source{Kind: SourceGit, Repository: "ssh://git@example.com/org/templates", Ref: "main", Subdir: "web"},
+		},
+		{"https://example.com/templates.zip//web", Source{Kind: SourceOther}},
+		{"s3::https://s3.amazonaws.com/bucket/templates", Source{Kind: SourceOther}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.templateURL, func(t *testing.T) {
+			if got := ParseSource(tt.templateURL); got != tt.expected {
+				t.Errorf("ParseSource(%q) = %+v, want %+v", tt.templateURL, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPinnedURL(t *testing.T) {
+	commit := "0123456789abcdef0123456789abcdef01234567"
+	tests := []struct {
+		templateURL string
+		expected    string
+	}{
+		{"github.com/org/templates//web", "github.com/org/templates//web?ref=" + commit},
+		{"github.com/org/templates//web?ref=main", "github.com/org/templates//web?ref=" + commit},
+		{"git::https://example.com/t.git//web?ref=main&depth=1", "git::https://example.com/t.git//web?depth=1&ref=" + commit},
+	}
+
+	for _, tt := range tests {
+		if got := PinnedURL(tt.templateURL, commit); got != tt.expected {
+			t.Errorf("PinnedURL(%q) = %q, want %q", tt.templateURL, got, tt.expected)
+		}
+	}
+}
+
+func TestMatchRef(t *testing.T) {
+	output := "1111111111111111111111111111111111111111\trefs/heads/v1\n" +
+		"2222222222222222222222222222222222222222\trefs/tags/v2\n" +
+		"3333333333333333333333333333333333333333\trefs/tags/v2^{}\n" +
+		"4444444444444444444444444444444444444444\trefs/tags/v1\n"
+
+	tests := map[string]string{
+		"v1":      "1111111111111111111111111111111111111111",
+		"v2":      "3333333333333333333333333333333333333333",
+		"missing": "",
+	}
+	for ref, expected := range tests {
+		if got := matchRef(output, ref); got != expected {
+			t.Errorf("matchRef(%q) = %q, want %q", ref, got, expected)
+		}
+	}
+}
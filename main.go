@@ -1,87 +1,206 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"boilerplate-compose/config"
-	"boilerplate-compose/processor"
-	"boilerplate-compose/executor"
 )
 
-var (
-	// Build-time variables set by goreleaser
-	version = "dev"
-	
-	// CLI flags
-	configFile      = flag.String("f", "", "Path to compose file")
-	showVersion     = flag.Bool("version", false, "Show version")
-	help            = flag.Bool("help", false, "Show help")
-	dryRun          = flag.Bool("dry-run", false, "Show what would be executed without running")
-	boilerplatePath = flag.String("boilerplate-path", "", "Path to boilerplate CLI (defaults to PATH lookup)")
-	verbose         = flag.Bool("verbose", false, "Show detailed output from boilerplate commands")
-	envFile         = flag.String("env-file", "", "Path to .env file (defaults to .env in current directory)")
-)
+// Build-time variables set by goreleaser
+var version = "dev"
 
-func main() {
-	if err := run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+// command is a subcommand of the CLI. run registers the command's flags on
+// fs, parses args with it and carries out the command.
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(fs *flag.FlagSet, args []string, stdout io.Writer) error
+}
+
+var commands = []command{
+	{"up", "up [options] [template...]", "Render all templates, or only those named (globs like 'svc-*' are allowed)", runUp},
+	{"plan", "plan [options] [template...]", "Show the files that up would create, modify or delete, with diffs", runPlan},
+	{"lock", "lock [options]", "Pin templates to commits and content hashes in a lock file", runLock},
+	{"update", "update [options] [template...]", "Move templates in the lock file to the latest commit of their ref", runUpdate},
+	{"validate", "validate [options]", "Check the compose file without running anything", runValidate},
+	{"ls", "ls [options]", "List templates in execution order", runList},
+	{"config", "config [options]", "Print the resolved compose file", runConfig},
+	{"version", "version", "Show version", runVersion},
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
 	}
+	return nil
 }
 
-func run() error {
-	flag.Parse()
+// runCommand parses args with a flag set of its own and runs cmd
+func runCommand(cmd *command, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n  boilerplate-compose %s\n\n%s\n", cmd.usage, cmd.summary)
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(fs.Output(), "\nOptions:")
+			fs.PrintDefaults()
+		}
+	}
 
-	if *help {
-		printUsage()
+	err := cmd.run(fs, args, stdout)
+	if errors.Is(err, flag.ErrHelp) {
 		return nil
 	}
+	return err
+}
 
-	if *showVersion {
-		fmt.Printf("boilerplate-compose version %s\n", version)
-		return nil
+// parseInterspersed parses args with fs, allowing flags after positional
+// arguments, and returns the positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+
+		// A lone "--" ends flag parsing; everything after it is positional
+		if len(args) > fs.NArg() && args[len(args)-fs.NArg()-1] == "--" {
+			return append(positional, fs.Args()...), nil
+		}
+
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
 	}
+}
+
+// loadFlags are the flags that control how the compose file is found and
+// how its variables are resolved. They are shared by every command.
+type loadFlags struct {
+	configFile    string
+	envFiles      stringList
+	envProfile    string
+	envPrecedence string
+	strict        bool
+}
+
+func (f *loadFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.configFile, "f", "", "Path to compose file")
+	fs.Var(&f.envFiles, "env-file", "Path to .env file, may be repeated (defaults to .env and .env.local in current directory)")
+	fs.StringVar(&f.envProfile, "env-profile", "", "Also load .env.<profile> and .env.<profile>.local when no -env-file is given. Does not enable any template; see -profile")
+	fs.StringVar(&f.envPrecedence, "env-precedence", string(config.PrecedenceShell), "Which wins when a variable is in both the shell and an env file: shell or file")
+	fs.BoolVar(&f.strict, "strict", false, "Fail if any variable in the compose file cannot be resolved")
+}
 
-	configPath := findConfigFile(*configFile)
+// loadConfig finds the compose file, sets up its environment and loads it.
+// It returns the config together with the path it was loaded from.
+func (f *loadFlags) loadConfig() (*config.ComposeConfig, string, error) {
+	configPath := findConfigFile(f.configFile)
 	if configPath == "" {
-		return fmt.Errorf("no compose file found. Use -f to specify a file")
+		return nil, "", fmt.Errorf("no compose file found. Use -f to specify a file")
+	}
+
+	precedence, err := config.ParsePrecedence(f.envPrecedence)
+	if err != nil {
+		return nil, "", err
 	}
 
 	// Set up environment manager
 	envManager := config.NewEnvironmentManager()
-	
-	// Load system environment first
+	envManager.SetPrecedence(precedence)
+
+	// Load system environment first; depending on the precedence, env
+	// files either skip or override these variables
 	envManager.LoadSystemEnvironment()
-	
-	// Load from .env file if specified or if default .env exists
-	envFilePath := findEnvFile(*envFile)
-	if envFilePath != "" {
-		if err := envManager.LoadEnvironmentFromFile(envFilePath); err != nil {
-			return fmt.Errorf("failed to load environment file: %w", err)
-		}
-	}
 
-	cfg, err := config.LoadConfigWithEnvironment(configPath, envManager)
+	// The loader loads the env files of the compose file, then the ones
+	// given on the command line or found in the current directory; later
+	// files win
+	cfg, err := config.LoadConfigWithOptions(configPath, config.LoadOptions{
+		Environment: envManager,
+		Strict:      f.strict,
+		EnvFiles:    findEnvFiles(f.envFiles, f.envProfile),
+	})
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return nil, "", fmt.Errorf("failed to load config: %w", err)
 	}
 
-	templateProcessor := processor.NewTemplateProcessor(cfg, configPath)
-	cliExecutor := executor.NewCliExecutor(*boilerplatePath, *verbose)
-	orchestrator := processor.NewOrchestrator(templateProcessor, cliExecutor, *dryRun)
+	return cfg, configPath, nil
+}
+
+// stringList is a flag that can be given multiple times
+type stringList []string
 
-	if err := orchestrator.Process(); err != nil {
-		return fmt.Errorf("processing failed: %w", err)
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "help", "-help", "--help", "-h":
+			return runHelp(args[1:], stdout)
+		case "-version", "--version":
+			return runCommand(findCommand("version"), args[1:], stdout)
+		}
+
+		if cmd := findCommand(args[0]); cmd != nil {
+			return runCommand(cmd, args[1:], stdout)
+		}
 
-	if *dryRun {
-		fmt.Println("\nDry run completed. Use without -dry-run to execute.")
-	} else {
-		fmt.Println("\nAll templates processed successfully.")
+		if !strings.HasPrefix(args[0], "-") {
+			return fmt.Errorf("unknown command %q. Run 'boilerplate-compose help' for usage", args[0])
+		}
 	}
-	
+
+	// Without a command the arguments are options for up, which keeps the
+	// flags from before there were subcommands working
+	return runCommand(findCommand("up"), args, stdout)
+}
+
+// runHelp prints the overall usage, or the usage of the named command
+func runHelp(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		printUsage(stdout)
+		return nil
+	}
+
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	return runCommand(cmd, []string{"-help"}, stdout)
+}
+
+func runVersion(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "boilerplate-compose version %s\n", version)
 	return nil
 }
 
@@ -100,27 +219,33 @@ func findConfigFile(specified string) string {
 	return ""
 }
 
-func findEnvFile(specified string) string {
-	if specified != "" {
+func findEnvFiles(specified []string, profile string) []string {
+	if len(specified) > 0 {
 		return specified
 	}
 
-	// Check for default .env file
-	if _, err := os.Stat(".env"); err == nil {
-		return ".env"
-	}
-
-	return ""
+	// Check for default .env files in the current directory
+	return config.DefaultEnvFiles(".", profile)
 }
 
-func printUsage() {
-	fmt.Println("boilerplate-compose - Orchestrate template rendering using boilerplate CLI")
-	fmt.Println("\nUsage:")
-	fmt.Println("  boilerplate-compose [options]")
-	fmt.Println("\nOptions:")
-	flag.PrintDefaults()
-	fmt.Println("\nExample:")
-	fmt.Println("  boilerplate-compose -f my-compose.yaml -verbose")
-	fmt.Println("  boilerplate-compose -dry-run")
-	fmt.Println("  boilerplate-compose -env-file production.env")
-}
\ No newline at end of file
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "boilerplate-compose - Orchestrate template rendering using boilerplate CLI")
+	fmt.Fprintln(w, "\nUsage:")
+	fmt.Fprintln(w, "  boilerplate-compose <command> [options]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w, "\nRun 'boilerplate-compose help <command>' for the options of a command.")
+	fmt.Fprintln(w, "\nExample:")
+	fmt.Fprintln(w, "  boilerplate-compose up -f my-compose.yaml -verbose")
+	fmt.Fprintln(w, "  boilerplate-compose up -dry-run")
+	fmt.Fprintln(w, "  boilerplate-compose up -env-file .env -env-file secrets.env")
+	fmt.Fprintln(w, "  boilerplate-compose up -env-profile staging")
+	fmt.Fprintln(w, "  boilerplate-compose up -parallel 8")
+	fmt.Fprintln(w, "  boilerplate-compose plan")
+	fmt.Fprintln(w, "  boilerplate-compose up -frozen")
+	fmt.Fprintln(w, "  boilerplate-compose validate")
+	fmt.Fprintln(w, "  boilerplate-compose ls")
+	fmt.Fprintln(w, "  boilerplate-compose config -format json")
+}
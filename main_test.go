@@ -1,7 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"boilerplate-compose/config"
 )
 
 func TestFindConfigFile(t *testing.T) {
@@ -32,4 +42,395 @@ func TestFindConfigFile_DefaultFiles(t *testing.T) {
 			t.Errorf("expected %q, got %q", expected, result)
 		}
 	})
-}
\ No newline at end of file
+}
+func TestFindEnvFiles(t *testing.T) {
+	t.Run("specified files replace discovery", func(t *testing.T) {
+		result := findEnvFiles([]string{"a.env", "b.env"}, "staging")
+		if len(result) != 2 || result[0] != "a.env" || result[1] != "b.env" {
+			t.Errorf("expected specified files in order, got %v", result)
+		}
+	})
+}
+
+func TestStringList(t *testing.T) {
+	var list stringList
+	for _, value := range []string{"a.env", "b.env"} {
+		if err := list.Set(value); err != nil {
+			t.Fatalf("Set(%q) error = %v", value, err)
+		}
+	}
+
+	if list.String() != "a.env,b.env" {
+		t.Errorf("expected %q, got %q", "a.env,b.env", list.String())
+	}
+}
+
+func TestRunConfig(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "compose.yaml")
+	content := `
+templates:
+  web:
+    template-url: ./templates/web
+    output-folder: ./web
+    vars:
+      name: ${APP_NAME:-demo}
+  api:
+    template-url: ./templates/api
+    output-folder: ./api
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("lists template names", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := run([]string{"config", "-f", configPath, "--services"}, &out); err != nil {
+			t.Fatalf("runConfig() error = %v", err)
+		}
+		if out.String() != "web\napi\n" {
+			t.Errorf("unexpected output %q", out.String())
+		}
+	})
+
+	t.Run("prints interpolated YAML", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := run([]string{"config", "-f", configPath}, &out); err != nil {
+			t.Fatalf("runConfig() error = %v", err)
+		}
+		if !strings.Contains(out.String(), "name: demo") {
+			t.Errorf("expected interpolated value, got:\n%s", out.String())
+		}
+	})
+
+	t.Run("resolves paths in JSON", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := run([]string{"config", "-f", configPath, "-format", "json", "-resolve-paths"}, &out); err != nil {
+			t.Fatalf("runConfig() error = %v", err)
+		}
+
+		var decoded struct {
+			Templates map[string]map[string]interface{} `json:"templates"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+		}
+		if got := decoded.Templates["web"]["output-folder"]; got != filepath.Join(dir, "web") {
+			t.Errorf("expected absolute output-folder, got %v", got)
+		}
+	})
+
+	t.Run("rejects unknown format", func(t *testing.T) {
+		if err := run([]string{"config", "-f", configPath, "-format", "toml"}, io.Discard); err == nil {
+			t.Error("expected error for unknown format")
+		}
+	})
+}
+
+func TestRunValidate(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "compose.yaml")
+	content := `templates:
+  web:
+    template-url: ./templates/web
+    output-folder: ./web
+    no_hooks: true
+  api:
+    template-url: ./templates/api
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("prints every problem", func(t *testing.T) {
+		var out bytes.Buffer
+		err := run([]string{"validate", "-f", configPath}, &out)
+		if err == nil || err.Error() != "2 problem(s) found" {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		expected := configPath + ":5:5: template 'web': unknown field 'no_hooks' (did you mean 'no-hooks'?)\n" +
+			configPath + ":7:5: template 'api': output-folder is required\n"
+		if out.String() != expected {
+			t.Errorf("unexpected output:\n%s", out.String())
+		}
+	})
+
+	t.Run("prints the schema", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := run([]string{"validate", "-schema"}, &out); err != nil {
+			t.Fatalf("runValidate() error = %v", err)
+		}
+		if !json.Valid(out.Bytes()) {
+			t.Errorf("schema is not valid JSON:\n%s", out.String())
+		}
+	})
+}
+
+func TestRunDispatch(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "compose.yaml")
+	content := `
+templates:
+  app:
+    template-url: ./templates/app
+    output-folder: ./app
+    depends-on: [base]
+  base:
+    template-url: ./templates/base
+    output-folder: ./base
+  docs:
+    template-url: ./templates/docs
+    output-folder: ./docs
+    profiles: [docs]
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		contains []string
+	}{
+		{"version command", []string{"version"}, []string{"boilerplate-compose version dev"}},
+		{"legacy version flag", []string{"-version"}, []string{"boilerplate-compose version dev"}},
+		{"help", []string{"help"}, []string{"Commands:", "validate", "config"}},
+		{"validate", []string{"validate", "-f", configPath}, []string{"is valid (3 templates)"}},
+		{"ls in execution order", []string{"ls", "-q", "-f", configPath}, []string{"base\napp\ndocs\n"}},
+		{"ls table", []string{"ls", "-f", configPath}, []string{"NAME", "DEPENDS ON", filepath.Join(dir, "app"), "PROFILES", "docs"}},
+		{"legacy up flags", []string{"-f", configPath, "-dry-run"}, []string{"Dry run completed"}},
+		{"up", []string{"up", "-f", configPath, "-dry-run"}, []string{"Dry run completed"}},
+		{"up selected templates", []string{"up", "base", "-f", configPath, "-dry-run"}, []string{"Dry run completed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := run(tt.args, &out); err != nil {
+				t.Fatalf("run(%v) error = %v", tt.args, err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+
+	t.Run("ls with a profile", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := run([]string{"ls", "-q", "-profile", "ci", "-f", configPath}, &out); err != nil {
+			t.Fatalf("run() error = %v", err)
+		}
+		if out.String() != "base\napp\n" {
+			t.Errorf("expected only templates enabled by the profile, got:\n%s", out.String())
+		}
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		err := run([]string{"deploy"}, io.Discard)
+		if err == nil || !strings.Contains(err.Error(), `unknown command "deploy"`) {
+			t.Errorf("expected unknown command error, got %v", err)
+		}
+	})
+
+	t.Run("validate reports cycles", func(t *testing.T) {
+		cyclePath := filepath.Join(dir, "cycle.yaml")
+		cycle := `
+templates:
+  a:
+    template-url: ./a
+    output-folder: ./a
+    depends-on: [b]
+  b:
+    template-url: ./b
+    output-folder: ./b
+    depends-on: [a]
+`
+		if err := os.WriteFile(cyclePath, []byte(cycle), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := run([]string{"validate", "-f", cyclePath}, io.Discard); err == nil {
+			t.Error("expected validate to fail on a dependency cycle")
+		}
+	})
+
+	t.Run("validate reports cycles between profile templates", func(t *testing.T) {
+		cyclePath := filepath.Join(dir, "profile-cycle.yaml")
+		cycle := `
+templates:
+  a:
+    template-url: ./a
+    output-folder: ./a
+    depends-on: [b]
+    profiles: [docs]
+  b:
+    template-url: ./b
+    output-folder: ./b
+    depends-on: [a]
+    profiles: [docs]
+`
+		if err := os.WriteFile(cyclePath, []byte(cycle), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := run([]string{"validate", "-f", cyclePath}, io.Discard); err == nil {
+			t.Error("expected validate to fail on a dependency cycle")
+		}
+	})
+}
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		positional []string
+		dryRun     bool
+	}{
+		{"flags first", []string{"-dry-run", "a", "b"}, []string{"a", "b"}, true},
+		{"flags last", []string{"a", "b", "-dry-run"}, []string{"a", "b"}, true},
+		{"flags between", []string{"a", "-dry-run", "b"}, []string{"a", "b"}, true},
+		{"double dash", []string{"a", "--", "-dry-run"}, []string{"a", "-dry-run"}, false},
+		{"no positional", []string{"-dry-run"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			dryRun := fs.Bool("dry-run", false, "")
+
+			positional, err := parseInterspersed(fs, tt.args)
+			if err != nil {
+				t.Fatalf("parseInterspersed() error = %v", err)
+			}
+			if !reflect.DeepEqual(positional, tt.positional) {
+				t.Errorf("positional = %v, want %v", positional, tt.positional)
+			}
+			if *dryRun != tt.dryRun {
+				t.Errorf("dry-run = %v, want %v", *dryRun, tt.dryRun)
+			}
+		})
+	}
+}
+
+func TestActiveProfiles(t *testing.T) {
+	t.Setenv(config.ProfilesEnvVar, "docs, ci")
+
+	if got := activeProfiles(nil); !reflect.DeepEqual(got, []string{"docs", "ci"}) {
+		t.Errorf("expected profiles from the environment, got %v", got)
+	}
+	if got := activeProfiles([]string{"observability", "a,b"}); !reflect.DeepEqual(got, []string{"observability", "a", "b"}) {
+		t.Errorf("expected profiles from flags, got %v", got)
+	}
+}
+
+func TestDryRunFlag(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected dryRunFlag
+		wantErr  bool
+	}{
+		{nil, "", false},
+		{[]string{"-dry-run"}, dryRunCommands, false},
+		{[]string{"-dry-run=diff"}, dryRunDiff, false},
+		{[]string{"-dry-run=false"}, "", false},
+		{[]string{"-dry-run=files"}, "", true},
+	}
+
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("up", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		var dryRun dryRunFlag
+		fs.Var(&dryRun, "dry-run", "")
+
+		err := fs.Parse(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if err == nil && dryRun != tt.expected {
+			t.Errorf("Parse(%v) = %q, want %q", tt.args, dryRun, tt.expected)
+		}
+	}
+}
+
+func TestRunLock(t *testing.T) {
+	dir := t.TempDir()
+	templateDir := filepath.Join(dir, "templates", "web")
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTemplate := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(templateDir, "boilerplate.yml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTemplate("variables: []\n")
+
+	configPath := filepath.Join(dir, "compose.yaml")
+	content := "templates:\n  web:\n    template-url: " + templateDir + "\n    output-folder: ./web\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	up := []string{"up", "-f", configPath, "-dry-run", "-frozen"}
+
+	if err := run(up, io.Discard); err == nil || !strings.Contains(err.Error(), "no lock file") {
+		t.Fatalf("expected -frozen to fail without a lock file, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"lock", "-f", configPath}, &out); err != nil {
+		t.Fatalf("lock error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Locked web at sha256:") {
+		t.Errorf("unexpected lock output:\n%s", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "compose.lock")); err != nil {
+		t.Fatalf("expected a lock file next to the compose file: %v", err)
+	}
+	if err := run(up, io.Discard); err != nil {
+		t.Fatalf("expected -frozen to pass with a current lock file, got %v", err)
+	}
+
+	writeTemplate("variables: [changed]\n")
+	err := run(up, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "template 'web': local template content changed") {
+		t.Fatalf("expected -frozen to fail with a stale lock file, got %v", err)
+	}
+
+	if err := run([]string{"update", "nope", "-f", configPath}, io.Discard); err == nil {
+		t.Error("expected update to fail for an unknown template")
+	}
+	out.Reset()
+	if err := run([]string{"update", "web", "-f", configPath}, &out); err != nil {
+		t.Fatalf("update error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Updated web from sha256:") {
+		t.Errorf("unexpected update output:\n%s", out.String())
+	}
+	if err := run(up, io.Discard); err != nil {
+		t.Errorf("expected -frozen to pass after update, got %v", err)
+	}
+
+	t.Run("compose files in the same directory", func(t *testing.T) {
+		otherPath := filepath.Join(dir, "other.yaml")
+		other := "templates:\n  docs:\n    template-url: " + templateDir + "\n    output-folder: ./docs\n"
+		if err := os.WriteFile(otherPath, []byte(other), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := run([]string{"lock", "-f", otherPath}, io.Discard); err != nil {
+			t.Fatalf("lock error = %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "other.lock")); err != nil {
+			t.Fatalf("expected a lock file named after the compose file: %v", err)
+		}
+		if err := run(up, io.Discard); err != nil {
+			t.Errorf("expected the lock file of %s to be kept, got %v", configPath, err)
+		}
+		if err := run([]string{"up", "-f", otherPath, "-dry-run", "-frozen"}, io.Discard); err != nil {
+			t.Errorf("expected -frozen to pass for %s, got %v", otherPath, err)
+		}
+	})
+}
@@ -0,0 +1,232 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"boilerplate-compose/config"
+	"boilerplate-compose/executor"
+)
+
+// Overlap is a pair of templates whose output folders are the same or one
+// is nested inside the other, so they may write the same files.
+type Overlap struct {
+	First      string
+	FirstPath  string
+	Second     string
+	SecondPath string
+}
+
+func (o Overlap) String() string {
+	if filepath.Clean(o.FirstPath) == filepath.Clean(o.SecondPath) {
+		return fmt.Sprintf("templates '%s' and '%s' both write to %s", o.First, o.Second, o.FirstPath)
+	}
+	return fmt.Sprintf("templates '%s' and '%s' write to nested output folders %s and %s", o.First, o.Second, o.FirstPath, o.SecondPath)
+}
+
+// FindOverlaps returns every pair of jobs with overlapping output folders,
+// in job order.
+func FindOverlaps(jobs []ProcessingJob) []Overlap {
+	var overlaps []Overlap
+	for i := range jobs {
+		for j := i + 1; j < len(jobs); j++ {
+			if pathsOverlap(jobs[i].OutputPath, jobs[j].OutputPath) {
+				overlaps = append(overlaps, Overlap{
+					First:      jobs[i].Name,
+					FirstPath:  jobs[i].OutputPath,
+					Second:     jobs[j].Name,
+					SecondPath: jobs[j].OutputPath,
+				})
+			}
+		}
+	}
+	return overlaps
+}
+
+// collisionTracker records which template wrote each file in overlapping
+// output folders, and applies the collision policy when a template writes a
+// file that another template already wrote. Templates with overlapping
+// output folders never run at the same time, so comparing a folder before
+// and after a template runs shows exactly the files it wrote.
+type collisionTracker struct {
+	policy config.CollisionPolicy
+	// tracked holds the templates whose output folder overlaps another's;
+	// no other template can collide.
+	tracked map[string]bool
+
+	mu sync.Mutex
+	// owners maps each file written during the run to the template whose
+	// version of it is on disk.
+	owners map[string]string
+}
+
+func newCollisionTracker(policy config.CollisionPolicy, overlaps []Overlap) *collisionTracker {
+	if policy == "" {
+		policy = config.DefaultCollisionPolicy
+	}
+
+	tracked := make(map[string]bool)
+	for _, overlap := range overlaps {
+		tracked[overlap.First] = true
+		tracked[overlap.Second] = true
+	}
+
+	return &collisionTracker{
+		policy:  policy,
+		tracked: tracked,
+		owners:  make(map[string]string),
+	}
+}
+
+func (t *collisionTracker) tracks(job ProcessingJob) bool {
+	return t != nil && t.tracked[job.Name]
+}
+
+// keepsFirst reports whether a collision is resolved by restoring the file
+// the first template wrote.
+func (t *collisionTracker) keepsFirst() bool {
+	return t.policy == config.CollisionError || t.policy == config.CollisionFirstWins
+}
+
+// fileState identifies a version of a file.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// backup is a copy of a file written by another template.
+type backup struct {
+	data []byte
+	mode fs.FileMode
+}
+
+// outputSnapshot is the state of an output folder before a template ran.
+type outputSnapshot struct {
+	files   map[string]fileState
+	backups map[string]backup
+}
+
+// before records the state of the job's output folder. When collisions keep
+// the first version, files written by other templates are also copied so
+// they can be restored.
+func (t *collisionTracker) before(job ProcessingJob) (*outputSnapshot, error) {
+	files, err := scanFolder(job.OutputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &outputSnapshot{files: files, backups: make(map[string]backup)}
+	if !t.keepsFirst() {
+		return snapshot, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for path := range files {
+		if owner, ok := t.owners[path]; !ok || owner == job.Name {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.backups[path] = backup{data: data, mode: info.Mode().Perm()}
+	}
+
+	return snapshot, nil
+}
+
+// after compares the job's output folder with the snapshot taken before it
+// ran, records the files the job wrote and resolves every collision
+// according to the policy.
+func (t *collisionTracker) after(job ProcessingJob, snapshot *outputSnapshot) ([]executor.Collision, error) {
+	files, err := scanFolder(job.OutputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for path, state := range files {
+		if previous, ok := snapshot.files[path]; !ok || previous != state {
+			written = append(written, path)
+		}
+	}
+	sort.Strings(written)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var collisions []executor.Collision
+	for _, path := range written {
+		owner, ok := t.owners[path]
+		if !ok || owner == job.Name {
+			t.owners[path] = job.Name
+			continue
+		}
+
+		collision := executor.Collision{File: path, Template: job.Name, Owner: owner, Kept: job.Name}
+		if b, ok := snapshot.backups[path]; ok && t.keepsFirst() {
+			if err := os.WriteFile(path, b.data, b.mode); err != nil {
+				return collisions, fmt.Errorf("failed to restore %s: %w", path, err)
+			}
+			collision.Kept = owner
+		} else {
+			t.owners[path] = job.Name
+		}
+		collisions = append(collisions, collision)
+
+		switch t.policy {
+		case config.CollisionWarn:
+			log.Printf("Warning: template '%s' overwrote %s, which template '%s' wrote", job.Name, path, owner)
+		case config.CollisionFirstWins, config.CollisionError:
+			log.Printf("Template '%s' wrote %s, which template '%s' already wrote; keeping the version of '%s'", job.Name, path, owner, collision.Kept)
+		}
+	}
+
+	return collisions, nil
+}
+
+// scanFolder returns the state of every file below dir by absolute path. A
+// folder that does not exist yet has no files.
+func scanFolder(dir string) (map[string]fileState, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]fileState)
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan output folder %s: %w", dir, err)
+	}
+
+	return files, nil
+}
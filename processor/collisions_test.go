@@ -0,0 +1,141 @@
+package processor
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"boilerplate-compose/config"
+	"boilerplate-compose/executor"
+)
+
+func TestFindOverlaps(t *testing.T) {
+	jobs := []ProcessingJob{
+		{Name: "app", OutputPath: "/out/app"},
+		{Name: "app-config", OutputPath: "/out/app/config"},
+		{Name: "docs", OutputPath: "/out/docs"},
+		{Name: "docs-copy", OutputPath: "/out/docs/"},
+		{Name: "apps", OutputPath: "/out/apps"},
+	}
+
+	var got []string
+	for _, overlap := range FindOverlaps(jobs) {
+		got = append(got, overlap.String())
+	}
+
+	expected := []string{
+		"templates 'app' and 'app-config' write to nested output folders /out/app and /out/app/config",
+		"templates 'docs' and 'docs-copy' both write to /out/docs",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("FindOverlaps() = %q, want %q", got, expected)
+	}
+}
+
+func TestFindOverlaps_RelativeFolders(t *testing.T) {
+	// A compose file in the working directory resolves output folders to
+	// relative paths such as "." and "sub"
+	cfg := &config.ComposeConfig{
+		Templates: map[string]config.Template{
+			"root": {TemplateURL: "./root", OutputFolder: "."},
+			"sub":  {TemplateURL: "./sub", OutputFolder: "./sub"},
+		},
+	}
+	jobs, err := NewTemplateProcessor(cfg, "boilerplate-compose.yaml").ExecutionPlan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	overlaps := FindOverlaps(jobs)
+	if len(overlaps) != 1 || overlaps[0].First != "root" || overlaps[0].Second != "sub" {
+		t.Errorf("FindOverlaps() = %v, want root and sub to overlap", overlaps)
+	}
+}
+
+func TestOrchestrator_FileCollisions(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	tests := []struct {
+		policy      config.CollisionPolicy
+		content     string
+		kept        string
+		expectError bool
+	}{
+		{config.CollisionWarn, "package app", "second", false},
+		{config.CollisionLastWins, "package app", "second", false},
+		{config.CollisionFirstWins, "package main", "first", false},
+		{config.CollisionError, "package main", "first", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := &config.ComposeConfig{
+				Templates: map[string]config.Template{
+					"first":  {TemplateURL: "./first", OutputFolder: "./out"},
+					"second": {TemplateURL: "./second", OutputFolder: "./out/sub", DependsOn: []string{"first"}},
+					"other":  {TemplateURL: "./other", OutputFolder: "./other"},
+				},
+			}
+			exec := &fakeExecutor{files: map[string]map[string]string{
+				"first":  {"README.md": "first", "sub/main.go": "package main"},
+				"second": {"README.md": "second", "main.go": "package app"},
+				"other":  {"README.md": "other"},
+			}}
+
+			tp := NewTemplateProcessor(cfg, filepath.Join(dir, "compose.yaml"))
+			reportPath := filepath.Join(dir, "report.json")
+			orch := NewOrchestratorWithOptions(tp, exec, Options{
+				CollisionPolicy: tt.policy,
+				Reports:         []executor.Report{{Format: executor.ReportJSON, Path: reportPath}},
+			})
+			err := orch.Process()
+			if tt.expectError != (err != nil) {
+				t.Fatalf("Process() error = %v, expectError %v", err, tt.expectError)
+			}
+
+			mainPath := filepath.Join(dir, "out", "sub", "main.go")
+			data, readErr := os.ReadFile(mainPath)
+			if readErr != nil {
+				t.Fatal(readErr)
+			}
+			if string(data) != tt.content {
+				t.Errorf("main.go = %q, want %q", data, tt.content)
+			}
+
+			data, readErr = os.ReadFile(reportPath)
+			if readErr != nil {
+				t.Fatal(readErr)
+			}
+			var report struct {
+				Templates []struct {
+					Name       string `json:"name"`
+					Collisions []struct {
+						File  string `json:"file"`
+						Owner string `json:"owner"`
+						Kept  string `json:"kept"`
+					} `json:"collisions"`
+				} `json:"templates"`
+			}
+			if err := json.Unmarshal(data, &report); err != nil {
+				t.Fatal(err)
+			}
+
+			var collisions []executor.Collision
+			for _, result := range report.Templates {
+				for _, c := range result.Collisions {
+					collisions = append(collisions, executor.Collision{File: c.File, Template: result.Name, Owner: c.Owner, Kept: c.Kept})
+				}
+			}
+			expected := []executor.Collision{{File: mainPath, Template: "second", Owner: "first", Kept: tt.kept}}
+			if !reflect.DeepEqual(collisions, expected) {
+				t.Errorf("collisions = %+v, want %+v", collisions, expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		expected string
+	}{
+		{
+			name:     "equal",
+			old:      "a\nb\n",
+			new:      "a\nb\n",
+			expected: "",
+		},
+		{
+			name: "changed line",
+			old:  "a\nb\nc\n",
+			new:  "a\nB\nc\n",
+			expected: `--- old
++++ new
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+`,
+		},
+		{
+			name: "new file",
+			old:  "",
+			new:  "a\nb\n",
+			expected: `--- old
++++ new
+@@ -0,0 +1,2 @@
++a
++b
+`,
+		},
+		{
+			name: "missing final newline",
+			old:  "a\n",
+			new:  "a",
+			expected: `--- old
++++ new
+@@ -1,1 +1,1 @@
+-a
++a
+\ No newline at end of file
+`,
+		},
+		{
+			name:     "binary",
+			old:      "a\x00",
+			new:      "b\x00",
+			expected: "Binary files old and new differ\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unifiedDiff("old", "new", []byte(tt.old), []byte(tt.new))
+			if got != tt.expected {
+				t.Errorf("unifiedDiff() =\n%s\nwant:\n%s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiffHunks(t *testing.T) {
+	var old, new strings.Builder
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&old, "%d\n", i)
+		switch i {
+		case 2, 4:
+			fmt.Fprintf(&new, "%d changed\n", i)
+		case 15:
+		default:
+			fmt.Fprintf(&new, "%d\n", i)
+		}
+	}
+
+	// Changes close together share a hunk; distant ones get their own
+	expected := `--- old
++++ new
+@@ -1,7 +1,7 @@
+ 1
+-2
++2 changed
+ 3
+-4
++4 changed
+ 5
+ 6
+ 7
+@@ -12,7 +12,6 @@
+ 12
+ 13
+ 14
+-15
+ 16
+ 17
+ 18
+`
+	if got := unifiedDiff("old", "new", []byte(old.String()), []byte(new.String())); got != expected {
+		t.Errorf("unifiedDiff() =\n%s\nwant:\n%s", got, expected)
+	}
+}
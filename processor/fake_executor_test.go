@@ -0,0 +1,78 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"boilerplate-compose/executor"
+)
+
+// fakeExecutor stands in for boilerplate in orchestrator tests. It records
+// the templates it runs and writes the files configured for each template
+// into its output folder.
+type fakeExecutor struct {
+	// files maps a template name to the files it writes, keyed by their path
+	// relative to the output folder.
+	files map[string]map[string]string
+	// fail lists templates that fail with a CommandError.
+	fail map[string]bool
+	// block lists templates that run until their context is done.
+	block map[string]bool
+	// started, when set, receives the name of every template as it starts.
+	started chan string
+	// revision is reported as the revision of every remote template. Without
+	// one, remote templates cannot be checked for changes.
+	revision string
+
+	mu  sync.Mutex
+	ran []string
+}
+
+func (e *fakeExecutor) Execute(ctx context.Context, args []string, templateName string) error {
+	e.mu.Lock()
+	e.ran = append(e.ran, templateName)
+	e.mu.Unlock()
+
+	if e.started != nil {
+		e.started <- templateName
+	}
+	if e.block[templateName] {
+		<-ctx.Done()
+		return &executor.CommandError{TemplateName: templateName, ExitCode: -1, Err: ctx.Err()}
+	}
+	if e.fail[templateName] {
+		return &executor.CommandError{TemplateName: templateName, ExitCode: 2, Stderr: "boom", Err: errors.New("exit status 2")}
+	}
+
+	var outputFolder string
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "--output-folder" {
+			outputFolder = args[i+1]
+		}
+	}
+	for name, content := range e.files[templateName] {
+		path := filepath.Join(outputFolder, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *fakeExecutor) CheckBoilerplateAvailable() error {
+	return nil
+}
+
+func (e *fakeExecutor) Revision(ctx context.Context, templateURL string) (string, error) {
+	if e.revision == "" {
+		return "", fmt.Errorf("the revision of %s is not known", templateURL)
+	}
+	return e.revision, nil
+}
@@ -0,0 +1,110 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// orderJobs sorts jobs so that every job comes after the jobs it depends on.
+// Jobs that do not depend on each other keep their declaration order.
+// Dependencies on templates that are not part of the job list are ignored.
+func orderJobs(jobs []ProcessingJob) ([]ProcessingJob, error) {
+	index := make(map[string]int, len(jobs))
+	for i, job := range jobs {
+		index[job.Name] = i
+	}
+
+	placed := make([]bool, len(jobs))
+	ordered := make([]ProcessingJob, 0, len(jobs))
+
+	for len(ordered) < len(jobs) {
+		progress := false
+		for i, job := range jobs {
+			if placed[i] || !dependenciesPlaced(job, index, placed) {
+				continue
+			}
+			placed[i] = true
+			ordered = append(ordered, job)
+			progress = true
+			// Restart from the top so earlier declarations win ties
+			break
+		}
+
+		if !progress {
+			return nil, fmt.Errorf("dependency cycle detected: %s", strings.Join(findCycle(jobs, index, placed), " -> "))
+		}
+	}
+
+	return ordered, nil
+}
+
+func dependenciesPlaced(job ProcessingJob, index map[string]int, placed []bool) bool {
+	for _, dep := range job.Template.DependsOn {
+		if i, ok := index[dep]; ok && !placed[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// findCycle returns a dependency path that starts and ends with the same
+// template, looking only at jobs that could not be placed.
+func findCycle(jobs []ProcessingJob, index map[string]int, placed []bool) []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(jobs))
+	var path []string
+
+	var visit func(i int) []string
+	visit = func(i int) []string {
+		state[i] = visiting
+		path = append(path, jobs[i].Name)
+
+		for _, dep := range jobs[i].Template.DependsOn {
+			j, ok := index[dep]
+			if !ok || placed[j] {
+				continue
+			}
+			switch state[j] {
+			case visiting:
+				for k, name := range path {
+					if name == dep {
+						return append(append([]string{}, path[k:]...), dep)
+					}
+				}
+			case unvisited:
+				if cycle := visit(j); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[i] = done
+		return nil
+	}
+
+	for i := range jobs {
+		if !placed[i] && state[i] == unvisited {
+			if cycle := visit(i); cycle != nil {
+				return cycle
+			}
+		}
+	}
+
+	return nil
+}
+
+// failedDependency returns the first dependency of the job that is in the
+// failed set, or an empty string if all dependencies completed.
+func failedDependency(job ProcessingJob, failed map[string]bool) string {
+	for _, dep := range job.Template.DependsOn {
+		if failed[dep] {
+			return dep
+		}
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package processor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"boilerplate-compose/config"
+)
+
+func testJob(name string, dependsOn ...string) ProcessingJob {
+	return ProcessingJob{
+		Name:     name,
+		Template: config.Template{DependsOn: dependsOn},
+	}
+}
+
+func jobNames(jobs []ProcessingJob) []string {
+	names := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		names = append(names, job.Name)
+	}
+	return names
+}
+
+func TestOrderJobs(t *testing.T) {
+	tests := []struct {
+		name     string
+		jobs     []ProcessingJob
+		expected []string
+	}{
+		{
+			name:     "no dependencies keeps declaration order",
+			jobs:     []ProcessingJob{testJob("c"), testJob("a"), testJob("b")},
+			expected: []string{"c", "a", "b"},
+		},
+		{
+			name:     "dependents run after their dependencies",
+			jobs:     []ProcessingJob{testJob("ci", "scaffold"), testJob("docs", "scaffold"), testJob("scaffold")},
+			expected: []string{"scaffold", "ci", "docs"},
+		},
+		{
+			name:     "chained dependencies",
+			jobs:     []ProcessingJob{testJob("c", "b"), testJob("b", "a"), testJob("a"), testJob("d")},
+			expected: []string{"a", "b", "c", "d"},
+		},
+		{
+			name:     "dependencies outside the job list are ignored",
+			jobs:     []ProcessingJob{testJob("b", "missing"), testJob("a")},
+			expected: []string{"b", "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ordered, err := orderJobs(tt.jobs)
+			if err != nil {
+				t.Fatalf("orderJobs() error = %v", err)
+			}
+			if got := jobNames(ordered); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("orderJobs() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOrderJobs_Cycle(t *testing.T) {
+	jobs := []ProcessingJob{
+		testJob("scaffold"),
+		testJob("a", "c"),
+		testJob("b", "a"),
+		testJob("c", "b"),
+	}
+
+	_, err := orderJobs(jobs)
+	if err == nil {
+		t.Fatal("Expected error for dependency cycle")
+	}
+	if !strings.Contains(err.Error(), "dependency cycle detected: a -> c -> b -> a") {
+		t.Errorf("Expected readable cycle path, got: %v", err)
+	}
+}
+
+func TestOrderJobs_SelfDependency(t *testing.T) {
+	_, err := orderJobs([]ProcessingJob{testJob("a", "a")})
+	if err == nil || !strings.Contains(err.Error(), "a -> a") {
+		t.Errorf("Expected self-dependency cycle error, got: %v", err)
+	}
+}
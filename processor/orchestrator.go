@@ -1,34 +1,122 @@
 package processor
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"boilerplate-compose/config"
 	"boilerplate-compose/executor"
 )
 
 type Orchestrator struct {
 	processor *TemplateProcessor
-	executor  *executor.CliExecutor
+	executor  executor.Executor
 	dryRun    bool
+	parallel  int
+	keepGoing bool
+	reports   []executor.Report
+	timeout   time.Duration
+	// collisionPolicy decides what happens when two templates write the
+	// same file
+	collisionPolicy config.CollisionPolicy
+	// collisions tracks the files written during the current run
+	collisions *collisionTracker
+
+	incremental    bool
+	force          bool
+	forceTemplates []string
+	// stateMu guards state and completed
+	stateMu sync.Mutex
+	// state is the state file during an incremental run, nil otherwise
+	state *runState
+	// completed holds the templates that completed in the current
+	// incremental run; the value is true when the template ran rather than
+	// being up to date
+	completed map[string]bool
+}
+
+// Options controls how the orchestrator runs templates.
+type Options struct {
+	// DryRun prints the boilerplate commands instead of running them.
+	DryRun bool
+	// Parallel is the maximum number of templates run at the same time.
+	// Values below 1 are treated as 1.
+	Parallel int
+	// KeepGoing runs every template whose dependencies completed instead of
+	// stopping at the first failure.
+	KeepGoing bool
+	// Reports are written after every run, including failed ones.
+	Reports []executor.Report
+	// Timeout limits how long each template may run, unless the template
+	// sets its own timeout. Zero means no limit.
+	Timeout time.Duration
+	// CollisionPolicy decides what happens when a template writes a file
+	// another template already wrote. Defaults to
+	// config.DefaultCollisionPolicy.
+	CollisionPolicy config.CollisionPolicy
+	// Incremental skips templates whose inputs and output folder are
+	// unchanged since they last completed, as recorded in the state file
+	// next to the compose file. It has no effect in dry-run mode.
+	Incremental bool
+	// Force runs every template in an incremental run, while still
+	// recording their state.
+	Force bool
+	// ForceTemplates names templates to run in an incremental run even
+	// when they are up to date.
+	ForceTemplates []string
 }
 
-func NewOrchestrator(processor *TemplateProcessor, exec *executor.CliExecutor, dryRun bool) *Orchestrator {
+func NewOrchestrator(processor *TemplateProcessor, exec executor.Executor, dryRun bool) *Orchestrator {
+	return NewOrchestratorWithOptions(processor, exec, Options{DryRun: dryRun})
+}
+
+func NewOrchestratorWithOptions(processor *TemplateProcessor, exec executor.Executor, opts Options) *Orchestrator {
+	parallel := opts.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
 	return &Orchestrator{
-		processor: processor,
-		executor:  exec,
-		dryRun:    dryRun,
+		processor:       processor,
+		executor:        exec,
+		dryRun:          opts.DryRun,
+		parallel:        parallel,
+		keepGoing:       opts.KeepGoing,
+		reports:         opts.Reports,
+		timeout:         opts.Timeout,
+		collisionPolicy: opts.CollisionPolicy,
+		incremental:     opts.Incremental,
+		force:           opts.Force,
+		forceTemplates:  opts.ForceTemplates,
 	}
 }
 
 func (o *Orchestrator) Process() error {
-	jobs, err := o.processor.BuildProcessingJobs()
+	return o.ProcessContext(context.Background())
+}
+
+// ProcessContext runs all templates. When ctx is cancelled no new templates
+// are started and running ones are stopped and reported as interrupted.
+func (o *Orchestrator) ProcessContext(ctx context.Context) error {
+	jobs, err := o.processor.ExecutionPlan()
 	if err != nil {
-		return fmt.Errorf("failed to build processing jobs: %w", err)
+		return err
+	}
+
+	if !o.incremental || o.dryRun {
+		return o.run(ctx, jobs)
 	}
+	return o.runIncremental(ctx, jobs)
+}
 
+// run executes jobs, prints the summary and writes the reports.
+func (o *Orchestrator) run(ctx context.Context, jobs []ProcessingJob) error {
 	if !o.dryRun {
 		// Check if boilerplate CLI is available
 		if err := o.executor.CheckBoilerplateAvailable(); err != nil {
@@ -36,37 +124,200 @@ func (o *Orchestrator) Process() error {
 		}
 	}
 
-	log.Printf("Processing %d templates", len(jobs))
+	if o.parallel > 1 {
+		log.Printf("Processing %d templates (up to %d in parallel)", len(jobs), o.parallel)
+	} else {
+		log.Printf("Processing %d templates", len(jobs))
+	}
+
+	overlaps := FindOverlaps(jobs)
+	for _, overlap := range overlaps {
+		log.Printf("Warning: %s", overlap)
+	}
+	o.collisions = newCollisionTracker(o.collisionPolicy, overlaps)
 
 	summary := executor.NewExecutionSummary()
 	startTime := time.Now()
 
-	for _, job := range jobs {
-		result := o.processJob(job)
+	results, stopped := o.runJobs(ctx, jobs)
+	for _, result := range results {
 		summary.AddResult(result)
+	}
 
-		// Stop on first failure unless in dry-run mode
-		if !result.Success && !o.dryRun {
-			summary.Print()
-			return fmt.Errorf("template processing failed, stopping execution")
-		}
+	if ctx.Err() != nil {
+		summary.TotalDuration = time.Since(startTime)
+		summary.Print()
+		o.writeReports(summary)
+		return fmt.Errorf("template processing interrupted")
+	}
+
+	if stopped {
+		summary.Print()
+		o.writeReports(summary)
+		return fmt.Errorf("template processing failed, stopping execution")
 	}
 
 	summary.TotalDuration = time.Since(startTime)
 	summary.Print()
 
-	if summary.FailureCount > 0 && !o.dryRun {
-		return fmt.Errorf("%d template(s) failed", summary.FailureCount)
+	if err := o.writeReports(summary); err != nil {
+		return err
+	}
+
+	if failures := summary.BlockingFailureCount(); failures > 0 && !o.dryRun {
+		return fmt.Errorf("%d template(s) failed", failures)
 	}
 
 	return nil
 }
 
-func (o *Orchestrator) processJob(job ProcessingJob) executor.ExecutionResult {
+// writeReports writes every configured report. A report that cannot be
+// written is logged and does not keep the others from being written.
+func (o *Orchestrator) writeReports(summary *executor.ExecutionSummary) error {
+	var failed []string
+	for _, report := range o.reports {
+		if err := report.Write(summary); err != nil {
+			log.Printf("Warning: %v", err)
+			failed = append(failed, report.Path)
+			continue
+		}
+		log.Printf("Wrote %s report to %s", report.Format, report.Path)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to write report(s): %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// runIncremental runs jobs, skipping those that are up to date according to
+// the state file, and records the state of every template that completed.
+func (o *Orchestrator) runIncremental(ctx context.Context, jobs []ProcessingJob) error {
+	for _, name := range o.forceTemplates {
+		if _, ok := o.processor.config.Templates[name]; !ok {
+			return fmt.Errorf("cannot force unknown template '%s'", name)
+		}
+	}
+
+	path := statePath(o.processor.configPath)
+	state, err := loadState(path)
+	if err != nil {
+		log.Printf("Warning: %v, running every template", err)
+	}
+	o.state, o.completed = state, make(map[string]bool)
+	defer func() {
+		o.state, o.completed = nil, nil
+	}()
+
+	runErr := o.run(ctx, jobs)
+
+	// Output folders are hashed once every template has finished, since
+	// templates with overlapping output folders change each other's
+	for _, job := range jobs {
+		entry, ok := state.Templates[job.Name]
+		if _, completed := o.completed[job.Name]; !ok || !completed {
+			continue
+		}
+		if entry.Output, err = outputHash(job.OutputPath, o.processor.configPath); err != nil {
+			delete(state.Templates, job.Name)
+			continue
+		}
+		state.Templates[job.Name] = entry
+	}
+
+	if err := state.save(path); err != nil {
+		if runErr != nil {
+			log.Printf("Warning: %v", err)
+			return runErr
+		}
+		return err
+	}
+	return runErr
+}
+
+// checkState returns the fingerprint of a job in an incremental run, and
+// whether the job is up to date: its fingerprint matches the one recorded
+// when it last completed, its output folder is unchanged since then and
+// none of its dependencies ran in this run. Templates whose output folder
+// overlaps another's always run, since what they leave behind depends on
+// the other templates.
+func (o *Orchestrator) checkState(ctx context.Context, job ProcessingJob) (string, bool) {
+	if o.state == nil {
+		return "", false
+	}
+
+	resolver, _ := o.executor.(RevisionResolver)
+	fp, err := fingerprint(ctx, job, resolver)
+	if err != nil {
+		log.Printf("Template '%s' cannot be checked for changes and always runs: %v", job.Name, err)
+		return "", false
+	}
+	if o.force || slices.Contains(o.forceTemplates, job.Name) || o.collisions.tracks(job) {
+		return fp, false
+	}
+
+	o.stateMu.Lock()
+	previous, ok := o.state.Templates[job.Name]
+	dependencyRan := false
+	for _, dep := range job.Template.DependsOn {
+		dependencyRan = dependencyRan || o.completed[dep]
+	}
+	o.stateMu.Unlock()
+
+	if !ok || previous.Fingerprint != fp || dependencyRan {
+		return fp, false
+	}
+	output, err := outputHash(job.OutputPath, o.processor.configPath)
+	return fp, err == nil && output == previous.Output
+}
+
+// recordState records the outcome of a job that ran in an incremental run.
+// Only successful jobs with a fingerprint can be up to date next time.
+func (o *Orchestrator) recordState(job ProcessingJob, fp string, success bool) {
+	if o.state == nil {
+		return
+	}
+
+	o.stateMu.Lock()
+	defer o.stateMu.Unlock()
+	if success {
+		o.completed[job.Name] = true
+	}
+	if !success || fp == "" {
+		delete(o.state.Templates, job.Name)
+		return
+	}
+	o.state.Templates[job.Name] = templateState{Fingerprint: fp, Completed: time.Now()}
+}
+
+// markUpToDate records a job that was skipped because it is up to date.
+func (o *Orchestrator) markUpToDate(job ProcessingJob) {
+	o.stateMu.Lock()
+	defer o.stateMu.Unlock()
+	o.completed[job.Name] = false
+}
+
+// jobTimeout returns how long a job may run, or zero for no limit
+func (o *Orchestrator) jobTimeout(job ProcessingJob) time.Duration {
+	if job.Template.Timeout > 0 {
+		return time.Duration(job.Template.Timeout)
+	}
+	return o.timeout
+}
+
+func (o *Orchestrator) processJob(ctx context.Context, job ProcessingJob) executor.ExecutionResult {
 	startTime := time.Now()
 	result := executor.ExecutionResult{
 		TemplateName: job.Name,
+		AllowFailure: job.Template.AllowFailure,
 		StartTime:    startTime,
+		Args:         job.Args,
+	}
+
+	fp, upToDate := o.checkState(ctx, job)
+	if upToDate {
+		o.markUpToDate(job)
+		return upToDateResult(job)
 	}
 
 	log.Printf("Processing template: %s", job.Name)
@@ -76,9 +327,43 @@ func (o *Orchestrator) processJob(job ProcessingJob) executor.ExecutionResult {
 		result.Success = err == nil
 		result.Error = err
 	} else {
-		err := o.executor.Execute(job.Args, job.Name)
+		var snapshot *outputSnapshot
+		if o.collisions.tracks(job) {
+			var err error
+			if snapshot, err = o.collisions.before(job); err != nil {
+				log.Printf("Warning: cannot check template '%s' for file collisions: %v", job.Name, err)
+			}
+		}
+
+		timeout := o.jobTimeout(job)
+		attempts, err := executor.ExecuteWithRetry(ctx, o.executor, job.Retry, timeout, job.Args, job.Name)
+		result.Attempts = attempts
 		result.Success = err == nil
 		result.Error = err
+
+		last := attempts[len(attempts)-1]
+		switch {
+		case err == nil:
+		case ctx.Err() != nil:
+			result.Status = executor.StatusInterrupted
+			result.Error = fmt.Errorf("interrupted: %w", err)
+		case last.TimedOut:
+			result.Error = fmt.Errorf("timed out after %v: %w", timeout, err)
+		}
+		if err != nil && len(attempts) > 1 {
+			result.Error = fmt.Errorf("%w (after %d attempts)", result.Error, len(attempts))
+		}
+
+		result.ExitCode = last.ExitCode
+		var cmdErr *executor.CommandError
+		if errors.As(err, &cmdErr) {
+			result.Stderr = cmdErr.Stderr
+		}
+
+		if snapshot != nil {
+			o.checkCollisions(job, snapshot, &result)
+		}
+		o.recordState(job, fp, result.Success)
 	}
 
 	result.EndTime = time.Now()
@@ -87,20 +372,87 @@ func (o *Orchestrator) processJob(job ProcessingJob) executor.ExecutionResult {
 	return result
 }
 
+// checkCollisions records the files the job wrote that other templates had
+// already written. With the error policy such a job fails.
+func (o *Orchestrator) checkCollisions(job ProcessingJob, snapshot *outputSnapshot, result *executor.ExecutionResult) {
+	collisions, err := o.collisions.after(job, snapshot)
+	if err != nil {
+		log.Printf("Warning: cannot check template '%s' for file collisions: %v", job.Name, err)
+	}
+	result.Collisions = collisions
+
+	if len(collisions) > 0 && o.collisions.policy == config.CollisionError && result.Success {
+		result.Success = false
+		result.Error = fmt.Errorf("wrote %d file(s) already written by other templates, starting with %s (written by '%s')",
+			len(collisions), collisions[0].File, collisions[0].Owner)
+	}
+}
+
+func skippedResult(job ProcessingJob, dependency string) executor.ExecutionResult {
+	log.Printf("Skipping template '%s': dependency '%s' did not complete", job.Name, dependency)
+
+	now := time.Now()
+	return executor.ExecutionResult{
+		TemplateName: job.Name,
+		Status:       executor.StatusSkipped,
+		Error:        fmt.Errorf("dependency '%s' did not complete", dependency),
+		StartTime:    now,
+		EndTime:      now,
+	}
+}
+
+// upToDateResult reports a job that was not run because it is up to date
+func upToDateResult(job ProcessingJob) executor.ExecutionResult {
+	log.Printf("Template '%s' is up to date, skipping", job.Name)
+
+	now := time.Now()
+	return executor.ExecutionResult{
+		TemplateName: job.Name,
+		Success:      true,
+		Status:       executor.StatusUpToDate,
+		AllowFailure: job.Template.AllowFailure,
+		Args:         job.Args,
+		StartTime:    now,
+		EndTime:      now,
+	}
+}
+
+// interruptedResult reports a job that was not started because the run was
+// interrupted
+func interruptedResult(job ProcessingJob) executor.ExecutionResult {
+	now := time.Now()
+	return executor.ExecutionResult{
+		TemplateName: job.Name,
+		Status:       executor.StatusSkipped,
+		Error:        fmt.Errorf("run was interrupted before it started"),
+		StartTime:    now,
+		EndTime:      now,
+	}
+}
+
 func (o *Orchestrator) dryRunJob(job ProcessingJob) error {
-	fmt.Printf("\n=== Template: %s ===\n", job.Name)
-	fmt.Printf("Command that would be executed:\n")
-	fmt.Printf("boilerplate %s\n", strings.Join(job.Args, " "))
-	fmt.Printf("\nTemplate details:\n")
-	fmt.Printf("  URL: %s\n", job.Template.TemplateURL)
-	fmt.Printf("  Output: %s\n", job.Template.OutputFolder)
+	// Build the whole block first so parallel jobs do not interleave output
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n=== Template: %s ===\n", job.Name)
+	fmt.Fprintf(&b, "Command that would be executed:\n")
+	fmt.Fprintf(&b, "boilerplate %s\n", strings.Join(job.Args, " "))
+	fmt.Fprintf(&b, "\nTemplate details:\n")
+	fmt.Fprintf(&b, "  URL: %s\n", job.Template.TemplateURL)
+	fmt.Fprintf(&b, "  Output: %s\n", job.Template.OutputFolder)
+	if len(job.Template.DependsOn) > 0 {
+		fmt.Fprintf(&b, "  Depends on: %s\n", strings.Join(job.Template.DependsOn, ", "))
+	}
+	if len(job.Template.Profiles) > 0 {
+		fmt.Fprintf(&b, "  Profiles: %s\n", strings.Join(job.Template.Profiles, ", "))
+	}
 
 	if len(job.Template.Vars) > 0 {
-		fmt.Printf("  Variables:\n")
-		for k, v := range job.Template.Vars {
-			fmt.Printf("    %s = %s\n", k, v)
+		fmt.Fprintf(&b, "  Variables:\n")
+		for _, k := range sortedKeys(job.Template.Vars) {
+			fmt.Fprintf(&b, "    %s = %s\n", k, job.Template.Vars[k])
 		}
 	}
 
+	fmt.Print(b.String())
 	return nil
 }
\ No newline at end of file
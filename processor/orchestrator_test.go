@@ -2,10 +2,15 @@ package processor
 
 import (
 	"bytes"
+	"context"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"boilerplate-compose/config"
 	"boilerplate-compose/executor"
@@ -111,7 +116,7 @@ func TestOrchestrator_ProcessJob(t *testing.T) {
 		os.Stdout = w
 
 		orch := NewOrchestrator(tp, exec, true)
-		result := orch.processJob(job)
+		result := orch.processJob(context.Background(), job)
 		
 		if !result.Success {
 			t.Fatalf("processJob() result error = %v", result.Error)
@@ -141,7 +146,7 @@ func TestOrchestrator_ProcessJob(t *testing.T) {
 		defer log.SetOutput(os.Stderr)
 
 		orch := NewOrchestrator(tp, exec, false)
-		result := orch.processJob(job)
+		result := orch.processJob(context.Background(), job)
 
 		if result.Success {
 			t.Fatal("Expected job to fail when boilerplate CLI is not available")
@@ -156,4 +161,293 @@ func TestOrchestrator_ProcessJob(t *testing.T) {
 			t.Error("Expected log message about processing template")
 		}
 	})
-}
\ No newline at end of file
+}
+// fakeBoilerplate writes a shell script that stands in for the boilerplate
+// CLI. It answers --version and fails for any template URL containing "fail".
+func fakeBoilerplate(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "boilerplate")
+	script := `#!/bin/sh
+case "$*" in
+  --version) echo "boilerplate v0.0.0"; exit 0 ;;
+  *fail*) echo "rendering failed" >&2; exit 3 ;;
+esac
+exit 0
+`
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake boilerplate: %v", err)
+	}
+	return path
+}
+
+func TestOrchestrator_Process_SkipsDependentsOfFailedTemplate(t *testing.T) {
+	cfg := &config.ComposeConfig{
+		Templates: map[string]config.Template{
+			"a-scaffold": {TemplateURL: "https://example.com/fail", OutputFolder: "./app"},
+			"b-ci":       {TemplateURL: "https://example.com/ci", OutputFolder: "./app/ci", DependsOn: []string{"a-scaffold"}},
+			"c-docs":     {TemplateURL: "https://example.com/docs", OutputFolder: "./app/docs", DependsOn: []string{"b-ci"}},
+		},
+	}
+
+	var logBuf bytes.Buffer
+	log.SetOutput(&logBuf)
+	defer log.SetOutput(os.Stderr)
+
+	tp := NewTemplateProcessor(cfg, filepath.Join(t.TempDir(), "config.yaml"))
+	orch := NewOrchestrator(tp, executor.NewCliExecutor(fakeBoilerplate(t), false), false)
+
+	err := orch.Process()
+	if err == nil {
+		t.Fatal("Expected error when a template fails")
+	}
+
+	logOutput := logBuf.String()
+	if !strings.Contains(logOutput, "Skipping template 'b-ci': dependency 'a-scaffold' did not complete") {
+		t.Errorf("Expected b-ci to be skipped, log:\n%s", logOutput)
+	}
+	if !strings.Contains(logOutput, "Skipping template 'c-docs': dependency 'b-ci' did not complete") {
+		t.Errorf("Expected c-docs to be skipped, log:\n%s", logOutput)
+	}
+}
+
+func TestOrchestrator_Process_DependencyCycle(t *testing.T) {
+	cfg := &config.ComposeConfig{
+		Templates: map[string]config.Template{
+			"a": {TemplateURL: "https://example.com/a", OutputFolder: "./a", DependsOn: []string{"b"}},
+			"b": {TemplateURL: "https://example.com/b", OutputFolder: "./b", DependsOn: []string{"a"}},
+		},
+	}
+
+	tp := NewTemplateProcessor(cfg, "/test/config.yaml")
+	orch := NewOrchestrator(tp, executor.NewCliExecutor("", false), true)
+
+	err := orch.Process()
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle detected") {
+		t.Errorf("Expected dependency cycle error, got: %v", err)
+	}
+}
+
+func TestOrchestrator_KeepGoing(t *testing.T) {
+	newConfig := func(allowFailure bool) *config.ComposeConfig {
+		return &config.ComposeConfig{
+			Templates: map[string]config.Template{
+				"a-scaffold": {TemplateURL: "https://example.com/fail", OutputFolder: "./app", AllowFailure: allowFailure},
+				"b-ci":       {TemplateURL: "https://example.com/ci", OutputFolder: "./app/ci", DependsOn: []string{"a-scaffold"}},
+				"c-docs":     {TemplateURL: "https://example.com/docs", OutputFolder: "./docs"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		keepGoing    bool
+		allowFailure bool
+		statuses     map[string]executor.ResultStatus
+		expectError  string
+	}{
+		{
+			name: "stops at first failure",
+			statuses: map[string]executor.ResultStatus{
+				"a-scaffold": executor.StatusFailed,
+				"b-ci":       executor.StatusSkipped,
+			},
+			expectError: "template processing failed, stopping execution",
+		},
+		{
+			name:      "keep going runs independent templates",
+			keepGoing: true,
+			statuses: map[string]executor.ResultStatus{
+				"a-scaffold": executor.StatusFailed,
+				"b-ci":       executor.StatusSkipped,
+				"c-docs":     executor.StatusSucceeded,
+			},
+			expectError: "1 template(s) failed",
+		},
+		{
+			name:         "allowed failure does not fail the run",
+			allowFailure: true,
+			statuses: map[string]executor.ResultStatus{
+				"a-scaffold": executor.StatusFailed,
+				"b-ci":       executor.StatusSkipped,
+				"c-docs":     executor.StatusSucceeded,
+			},
+		},
+	}
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := NewTemplateProcessor(newConfig(tt.allowFailure), "/test/config.yaml")
+			orch := NewOrchestratorWithOptions(tp, executor.NewCliExecutor(fakeBoilerplate(t), false), Options{KeepGoing: tt.keepGoing})
+
+			jobs, err := tp.ExecutionPlan()
+			if err != nil {
+				t.Fatalf("ExecutionPlan() error = %v", err)
+			}
+			results, _ := orch.runJobs(context.Background(), jobs)
+
+			statuses := make(map[string]executor.ResultStatus)
+			for _, result := range results {
+				summary := executor.NewExecutionSummary()
+				summary.AddResult(result)
+				statuses[result.TemplateName] = summary.Results[0].Status
+			}
+			if !reflect.DeepEqual(statuses, tt.statuses) {
+				t.Errorf("statuses = %v, want %v", statuses, tt.statuses)
+			}
+
+			err = orch.Process()
+			switch {
+			case tt.expectError == "" && err != nil:
+				t.Errorf("Process() error = %v", err)
+			case tt.expectError != "" && (err == nil || err.Error() != tt.expectError):
+				t.Errorf("Process() error = %v, want %q", err, tt.expectError)
+			}
+		})
+	}
+}
+
+func TestOrchestrator_WritesReportsOnFailure(t *testing.T) {
+	cfg := &config.ComposeConfig{
+		Templates: map[string]config.Template{
+			"broken": {TemplateURL: "https://example.com/fail", OutputFolder: "./broken"},
+		},
+	}
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+	tp := NewTemplateProcessor(cfg, "/test/config.yaml")
+	orch := NewOrchestratorWithOptions(tp, executor.NewCliExecutor(fakeBoilerplate(t), false), Options{
+		Reports: []executor.Report{{Format: executor.ReportJSON, Path: reportPath}},
+	})
+
+	if err := orch.Process(); err == nil {
+		t.Fatal("Expected error when a template fails")
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("Expected report to be written: %v", err)
+	}
+	for _, want := range []string{`"name": "broken"`, `"status": "failed"`, `"exit_code": 3`, `"stderr": "rendering failed"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected report to contain %s, got:\n%s", want, data)
+		}
+	}
+}
+
+func TestOrchestrator_CustomExecutor(t *testing.T) {
+	cfg := &config.ComposeConfig{
+		Templates: map[string]config.Template{
+			"base": {TemplateURL: "./base", OutputFolder: "./base"},
+			"app":  {TemplateURL: "./app", OutputFolder: "./app", DependsOn: []string{"base"}},
+			"docs": {TemplateURL: "./docs", OutputFolder: "./docs"},
+		},
+	}
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	exec := &fakeExecutor{fail: map[string]bool{"docs": true}}
+	orch := NewOrchestratorWithOptions(NewTemplateProcessor(cfg, "/test/config.yaml"), exec, Options{KeepGoing: true})
+
+	jobs, err := orch.processor.ExecutionPlan()
+	if err != nil {
+		t.Fatalf("ExecutionPlan() error = %v", err)
+	}
+	results, _ := orch.runJobs(context.Background(), jobs)
+
+	if !reflect.DeepEqual(exec.ran, []string{"base", "app", "docs"}) {
+		t.Errorf("unexpected execution order %v", exec.ran)
+	}
+
+	docs := results[2]
+	if docs.TemplateName != "docs" || docs.Success || docs.ExitCode != 2 || docs.Stderr != "boom" {
+		t.Errorf("unexpected result for docs: %+v", docs)
+	}
+}
+
+func TestOrchestrator_Timeout(t *testing.T) {
+	cfg := &config.ComposeConfig{
+		Templates: map[string]config.Template{
+			"fast": {TemplateURL: "./fast", OutputFolder: "./fast"},
+			"slow": {TemplateURL: "./slow", OutputFolder: "./slow", Timeout: config.Duration(50 * time.Millisecond)},
+		},
+	}
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	exec := &fakeExecutor{block: map[string]bool{"slow": true}}
+	orch := NewOrchestratorWithOptions(NewTemplateProcessor(cfg, "/test/config.yaml"), exec, Options{
+		KeepGoing: true,
+		Timeout:   time.Hour,
+	})
+
+	jobs, err := orch.processor.ExecutionPlan()
+	if err != nil {
+		t.Fatalf("ExecutionPlan() error = %v", err)
+	}
+	results, _ := orch.runJobs(context.Background(), jobs)
+
+	if !results[0].Success {
+		t.Errorf("expected fast to succeed, got %v", results[0].Error)
+	}
+	slow := results[1]
+	if slow.Success || slow.Status == executor.StatusInterrupted {
+		t.Fatalf("expected slow to fail, got %+v", slow)
+	}
+	if !strings.Contains(slow.Error.Error(), "timed out after 50ms") {
+		t.Errorf("unexpected error %v", slow.Error)
+	}
+}
+
+func TestOrchestrator_Interrupted(t *testing.T) {
+	cfg := &config.ComposeConfig{
+		Templates: map[string]config.Template{
+			"a-running": {TemplateURL: "./a", OutputFolder: "./a"},
+			"b-waiting": {TemplateURL: "./b", OutputFolder: "./b"},
+		},
+	}
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	exec := &fakeExecutor{
+		block:   map[string]bool{"a-running": true, "b-waiting": true},
+		started: make(chan string, 2),
+	}
+	orch := NewOrchestratorWithOptions(NewTemplateProcessor(cfg, "/test/config.yaml"), exec, Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-exec.started
+		cancel()
+	}()
+
+	jobs, err := orch.processor.ExecutionPlan()
+	if err != nil {
+		t.Fatalf("ExecutionPlan() error = %v", err)
+	}
+	results, _ := orch.runJobs(ctx, jobs)
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Status != executor.StatusInterrupted {
+		t.Errorf("expected a-running to be interrupted, got %+v", results[0])
+	}
+	if results[1].Status != executor.StatusSkipped {
+		t.Errorf("expected b-waiting to be skipped, got %+v", results[1])
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := orch.ProcessContext(ctx); err == nil || err.Error() != "template processing interrupted" {
+		t.Errorf("ProcessContext() error = %v", err)
+	}
+}
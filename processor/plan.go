@@ -0,0 +1,379 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ChangeKind is how a file would change when the templates are rendered.
+type ChangeKind string
+
+const (
+	ChangeCreated  ChangeKind = "created"
+	ChangeModified ChangeKind = "modified"
+	ChangeDeleted  ChangeKind = "deleted"
+)
+
+// FileChange is a file that rendering the templates would change.
+type FileChange struct {
+	// Path is the absolute path of the file in its output folder.
+	Path string
+	Kind ChangeKind
+	// Diff holds the change in unified diff format.
+	Diff string
+}
+
+// Plan renders every template into a temporary staging area instead of its
+// output folder and returns the files that running them would create,
+// modify or delete. The staging area starts as a copy of the current output
+// folders, so templates see the same files they would see in a real run.
+// Output folders are not changed.
+func (o *Orchestrator) Plan(ctx context.Context) ([]FileChange, error) {
+	jobs, err := o.processor.ExecutionPlan()
+	if err != nil {
+		return nil, err
+	}
+
+	staging, err := os.MkdirTemp("", "boilerplate-compose-plan-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging area: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	roots, err := outputRoots(jobs)
+	if err != nil {
+		return nil, err
+	}
+	for _, root := range roots {
+		if err := copyTree(root, stagedPath(staging, root)); err != nil {
+			return nil, fmt.Errorf("failed to copy %s to the staging area: %w", root, err)
+		}
+	}
+
+	staged := make([]ProcessingJob, len(jobs))
+	for i, job := range jobs {
+		if staged[i], err = stageJob(job, staging); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := o.run(ctx, staged); err != nil {
+		return nil, err
+	}
+
+	var changes []FileChange
+	for _, root := range roots {
+		rootChanges, err := compareTrees(root, stagedPath(staging, root))
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, rootChanges...)
+	}
+
+	return changes, nil
+}
+
+// outputRoots returns the absolute output folders of jobs, leaving out
+// folders nested inside another one.
+func outputRoots(jobs []ProcessingJob) ([]string, error) {
+	var folders []string
+	for _, job := range jobs {
+		folder, err := filepath.Abs(job.OutputPath)
+		if err != nil {
+			return nil, err
+		}
+		folders = append(folders, folder)
+	}
+	sort.Strings(folders)
+
+	// Sorted, a folder comes after every folder it is nested in
+	var roots []string
+	for _, folder := range folders {
+		nested := false
+		for _, root := range roots {
+			if pathsOverlap(root, folder) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			roots = append(roots, folder)
+		}
+	}
+	return roots, nil
+}
+
+// stagedPath returns where an output folder is rendered in the staging area.
+// Absolute paths are kept below the staging directory, so nested output
+// folders stay nested.
+func stagedPath(staging, folder string) string {
+	return filepath.Join(staging, strings.TrimPrefix(folder, filepath.VolumeName(folder)))
+}
+
+// stageJob returns a copy of job that renders into the staging area.
+func stageJob(job ProcessingJob, staging string) (ProcessingJob, error) {
+	folder, err := filepath.Abs(job.OutputPath)
+	if err != nil {
+		return job, err
+	}
+
+	job.OutputPath = stagedPath(staging, folder)
+	job.Args = append([]string(nil), job.Args...)
+	for i := 0; i+1 < len(job.Args); i++ {
+		if job.Args[i] == "--output-folder" {
+			job.Args[i+1] = job.OutputPath
+		}
+	}
+	return job, nil
+}
+
+// copyTree copies the regular files and directories below src to dst,
+// leaving out .git directories. A missing src leaves dst empty.
+func copyTree(src, dst string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == src {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() && entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case entry.Type().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// compareTrees returns the changes that turn the files below current into
+// the files below staged. Files are only read when they differ, so large
+// unchanged files cost one streaming comparison.
+func compareTrees(current, staged string) ([]FileChange, error) {
+	before, err := listTree(current)
+	if err != nil {
+		return nil, err
+	}
+	after, err := listTree(staged)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]bool, len(after))
+	for rel := range before {
+		paths[rel] = true
+	}
+	for rel := range after {
+		paths[rel] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for rel := range paths {
+		sorted = append(sorted, rel)
+	}
+	sort.Strings(sorted)
+
+	var changes []FileChange
+	for _, rel := range sorted {
+		path := filepath.Join(current, rel)
+		newPath := filepath.Join(staged, rel)
+		oldSize, existed := before[rel]
+		newSize, exists := after[rel]
+
+		change := FileChange{Path: path}
+		oldName, newName := "a/"+filepath.ToSlash(rel), "b/"+filepath.ToSlash(rel)
+		switch {
+		case !existed:
+			change.Kind = ChangeCreated
+			oldName = "/dev/null"
+		case !exists:
+			change.Kind = ChangeDeleted
+			newName = "/dev/null"
+		default:
+			if oldSize == newSize {
+				same, err := sameContent(path, newPath)
+				if err != nil {
+					return nil, err
+				}
+				if same {
+					continue
+				}
+			}
+			change.Kind = ChangeModified
+		}
+
+		var oldData, newData []byte
+		if existed {
+			if oldData, err = os.ReadFile(path); err != nil {
+				return nil, err
+			}
+		}
+		if exists {
+			if newData, err = os.ReadFile(newPath); err != nil {
+				return nil, err
+			}
+		}
+
+		change.Diff = unifiedDiff(oldName, newName, oldData, newData)
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// listTree returns the size of every regular file below dir by relative
+// path, leaving out .git directories. A missing dir has no files.
+func listTree(dir string) (map[string]int64, error) {
+	files := make(map[string]int64)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == dir {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() && entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files[rel] = info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// sameContent reports whether two files of the same size hold the same
+// bytes, reading them in chunks.
+func sameContent(a, b string) (bool, error) {
+	fa, err := os.Open(a)
+	if err != nil {
+		return false, err
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer fb.Close()
+
+	bufA := make([]byte, 64*1024)
+	bufB := make([]byte, 64*1024)
+	for {
+		na, errA := io.ReadFull(fa, bufA)
+		nb, errB := io.ReadFull(fb, bufB)
+		if !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false, nil
+		}
+		doneA := errA == io.EOF || errA == io.ErrUnexpectedEOF
+		doneB := errB == io.EOF || errB == io.ErrUnexpectedEOF
+		if errA != nil && !doneA {
+			return false, errA
+		}
+		if errB != nil && !doneB {
+			return false, errB
+		}
+		if doneA || doneB {
+			return doneA && doneB, nil
+		}
+	}
+}
+
+// WritePlan writes the file changes of a plan, followed by their diffs
+// when showDiff is set. Paths are shown relative to the current directory
+// where possible.
+func WritePlan(w io.Writer, changes []FileChange, showDiff bool) {
+	counts := make(map[ChangeKind]int)
+	for _, change := range changes {
+		counts[change.Kind]++
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "\nNo changes. Output folders are up to date.")
+		return
+	}
+
+	fmt.Fprintf(w, "\nPlan: %d to create, %d to modify, %d to delete\n\n",
+		counts[ChangeCreated], counts[ChangeModified], counts[ChangeDeleted])
+	markers := map[ChangeKind]string{ChangeCreated: "+", ChangeModified: "~", ChangeDeleted: "-"}
+	for _, change := range changes {
+		fmt.Fprintf(w, "  %s %s\n", markers[change.Kind], displayPath(change.Path))
+	}
+
+	if showDiff {
+		for _, change := range changes {
+			fmt.Fprintf(w, "\ndiff %s\n%s", displayPath(change.Path), change.Diff)
+		}
+	}
+}
+
+// displayPath returns path relative to the current directory when it is
+// below it.
+func displayPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,141 @@
+package processor
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"boilerplate-compose/config"
+)
+
+func TestOrchestrator_Plan(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	dir := t.TempDir()
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(dir, "app", "main.go"), "package main\n")
+	writeFile(filepath.Join(dir, "app", "README.md"), "unchanged\n")
+	writeFile(filepath.Join(dir, "app", "VERSION"), "1.0\n")
+	writeFile(filepath.Join(dir, "app", ".git", "HEAD"), "ref: refs/heads/main\n")
+
+	cfg := &config.ComposeConfig{
+		Templates: map[string]config.Template{
+			"app":  {TemplateURL: "./app", OutputFolder: "./app"},
+			"docs": {TemplateURL: "./docs", OutputFolder: "./app/docs"},
+		},
+	}
+	exec := &fakeExecutor{files: map[string]map[string]string{
+		"app":  {"main.go": "package app\n", "README.md": "unchanged\n", "VERSION": "2.0\n"},
+		"docs": {"index.md": "# Docs\n"},
+	}}
+
+	tp := NewTemplateProcessor(cfg, filepath.Join(dir, "compose.yaml"))
+	changes, err := NewOrchestrator(tp, exec, false).Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	var got []string
+	for _, change := range changes {
+		got = append(got, string(change.Kind)+" "+change.Path)
+	}
+	// The .git directory is not staged, and so not reported as deleted
+	expected := []string{
+		"modified " + filepath.Join(dir, "app", "VERSION"),
+		"created " + filepath.Join(dir, "app", "docs", "index.md"),
+		"modified " + filepath.Join(dir, "app", "main.go"),
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("changes = %v, want %v", got, expected)
+	}
+	if !strings.Contains(changes[0].Diff, "-1.0\n+2.0\n") {
+		t.Errorf("unexpected diff:\n%s", changes[0].Diff)
+	}
+	if !strings.Contains(changes[2].Diff, "-package main\n+package app\n") {
+		t.Errorf("unexpected diff:\n%s", changes[2].Diff)
+	}
+
+	// The output folders are left as they were
+	data, err := os.ReadFile(filepath.Join(dir, "app", "main.go"))
+	if err != nil || string(data) != "package main\n" {
+		t.Errorf("main.go = %q, %v; want it unchanged", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app", "docs")); !os.IsNotExist(err) {
+		t.Errorf("expected docs folder not to be created, got %v", err)
+	}
+}
+
+func TestCopyTree_SkipsGit(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	for _, rel := range []string{"main.go", filepath.Join(".git", "HEAD"), filepath.Join("sub", ".git", "config")} {
+		path := filepath.Join(src, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(rel), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := copyTree(src, dst); err != nil {
+		t.Fatalf("copyTree() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dst, "main.go")); err != nil {
+		t.Errorf("expected main.go to be copied: %v", err)
+	}
+	for _, rel := range []string{".git", filepath.Join("sub", ".git")} {
+		if _, err := os.Stat(filepath.Join(dst, rel)); !os.IsNotExist(err) {
+			t.Errorf("expected %s not to be copied, got %v", rel, err)
+		}
+	}
+}
+
+func TestSameContent(t *testing.T) {
+	dir := t.TempDir()
+	big := strings.Repeat("x", 200*1024)
+	files := map[string]string{
+		"a":     big + "a",
+		"same":  big + "a",
+		"other": big + "b",
+		"blank": "",
+		"empty": "",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"a", "same", true},
+		{"a", "other", false},
+		{"blank", "empty", true},
+	}
+	for _, tt := range tests {
+		got, err := sameContent(filepath.Join(dir, tt.a), filepath.Join(dir, tt.b))
+		if err != nil {
+			t.Fatalf("sameContent(%s, %s) error = %v", tt.a, tt.b, err)
+		}
+		if got != tt.expected {
+			t.Errorf("sameContent(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package processor
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"boilerplate-compose/executor"
+)
+
+type jobOutcome struct {
+	index  int
+	result executor.ExecutionResult
+}
+
+// runJobs executes jobs, which must already be in dependency order, running
+// up to o.parallel of them at a time. A job starts once its dependencies have
+// finished and every earlier job writing to an overlapping output folder has
+// finished, so overlapping templates still apply in order. Results are
+// returned in job order. After the first failure no new jobs are started
+// and stopped is true, except in dry-run or keep-going mode or when the
+// failed template is allowed to fail. Jobs depending on a failed template are
+// always skipped. Once ctx is cancelled no new jobs are started and jobs
+// that never started are reported as skipped.
+func (o *Orchestrator) runJobs(ctx context.Context, jobs []ProcessingJob) (results []executor.ExecutionResult, stopped bool) {
+	parallel := o.parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	outcomes := make([]*executor.ExecutionResult, len(jobs))
+	started := make([]bool, len(jobs))
+	finished := make([]bool, len(jobs))
+	// failed holds the names of templates that did not complete
+	failed := make(map[string]bool)
+	index := make(map[string]int, len(jobs))
+	for i, job := range jobs {
+		index[job.Name] = i
+	}
+
+	done := make(chan jobOutcome)
+	running := 0
+
+	for {
+		for i, job := range jobs {
+			if stopped || running >= parallel || ctx.Err() != nil {
+				break
+			}
+			if started[i] {
+				continue
+			}
+
+			if dep := failedDependency(job, failed); dep != "" {
+				result := skippedResult(job, dep)
+				outcomes[i] = &result
+				started[i], finished[i] = true, true
+				failed[job.Name] = true
+				continue
+			}
+
+			if !o.canStart(i, jobs, index, finished) {
+				continue
+			}
+
+			started[i] = true
+			running++
+			go func(i int, job ProcessingJob) {
+				done <- jobOutcome{index: i, result: o.processJob(ctx, job)}
+			}(i, job)
+		}
+
+		if running == 0 {
+			break
+		}
+
+		outcome := <-done
+		running--
+		finished[outcome.index] = true
+		outcomes[outcome.index] = &outcome.result
+
+		if !outcome.result.Success {
+			job := jobs[outcome.index]
+			failed[job.Name] = true
+
+			// Stop on the first failure unless in dry-run or keep-going
+			// mode, or the template is allowed to fail
+			switch {
+			case o.dryRun:
+			case job.Template.AllowFailure:
+				log.Printf("Template '%s' failed but is allowed to fail, continuing", job.Name)
+			case o.keepGoing:
+				log.Printf("Template '%s' failed, continuing with templates that do not depend on it", job.Name)
+			default:
+				stopped = true
+			}
+		}
+	}
+
+	// Jobs that were never started because execution stopped are only
+	// reported when they depend on a template that failed, or when the run
+	// was interrupted.
+	for i, job := range jobs {
+		if started[i] {
+			continue
+		}
+		if dep := failedDependency(job, failed); dep != "" {
+			result := skippedResult(job, dep)
+			outcomes[i] = &result
+			failed[job.Name] = true
+		} else if ctx.Err() != nil {
+			result := interruptedResult(job)
+			outcomes[i] = &result
+		}
+	}
+
+	for _, outcome := range outcomes {
+		if outcome != nil {
+			results = append(results, *outcome)
+		}
+	}
+
+	return results, stopped
+}
+
+// canStart reports whether the job at position i has no unfinished
+// dependencies and no unfinished earlier job with an overlapping output.
+func (o *Orchestrator) canStart(i int, jobs []ProcessingJob, index map[string]int, finished []bool) bool {
+	for _, dep := range jobs[i].Template.DependsOn {
+		if j, ok := index[dep]; ok && !finished[j] {
+			return false
+		}
+	}
+
+	for j := 0; j < i; j++ {
+		if !finished[j] && pathsOverlap(jobs[i].OutputPath, jobs[j].OutputPath) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// pathsOverlap reports whether two output folders are the same or one is
+// nested inside the other. Relative folders are compared as absolute paths,
+// so "." overlaps every folder below the working directory.
+func pathsOverlap(a, b string) bool {
+	a, b = absPath(a), absPath(b)
+	if a == b {
+		return true
+	}
+
+	sep := string(filepath.Separator)
+	return strings.HasPrefix(a, strings.TrimSuffix(b, sep)+sep) ||
+		strings.HasPrefix(b, strings.TrimSuffix(a, sep)+sep)
+}
+
+// absPath returns the absolute form of path, or path cleaned when the
+// working directory cannot be determined.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
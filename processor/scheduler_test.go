@@ -0,0 +1,147 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"boilerplate-compose/config"
+	"boilerplate-compose/executor"
+)
+
+func TestPathsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"/app", "/app", true},
+		{"/app", "/app/ci", true},
+		{"/app/ci/", "/app", true},
+		{"/app", "/application", false},
+		{"/app/ci", "/app/docs", false},
+		{".", "sub", true},
+		{"sub", "./", true},
+		{"./sub/", "sub/ci", true},
+		{"sub", "subway", false},
+		{"..", ".", true},
+	}
+
+	for _, tt := range tests {
+		if got := pathsOverlap(tt.a, tt.b); got != tt.expected {
+			t.Errorf("pathsOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+// timelineBoilerplate writes a fake boilerplate CLI that appends
+// "start <folder>" and "end <folder>" lines to a log file around a short
+// sleep, and returns the CLI path and the log path.
+func timelineBoilerplate(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "timeline.log")
+	path := filepath.Join(dir, "boilerplate")
+	script := `#!/bin/sh
+[ "$1" = "--version" ] && exit 0
+name=$(basename "$4")
+echo "start $name" >> "` + logPath + `"
+sleep 0.3
+echo "end $name" >> "` + logPath + `"
+`
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake boilerplate: %v", err)
+	}
+	return path, logPath
+}
+
+func readTimeline(t *testing.T, logPath string) []string {
+	t.Helper()
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read timeline: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func runParallel(t *testing.T, cfg *config.ComposeConfig, boilerplatePath string, parallel int) []executor.ExecutionResult {
+	t.Helper()
+
+	var logBuf bytes.Buffer
+	log.SetOutput(&logBuf)
+	defer log.SetOutput(os.Stderr)
+
+	tp := NewTemplateProcessor(cfg, filepath.Join(t.TempDir(), "config.yaml"))
+	orch := NewOrchestratorWithOptions(tp, executor.NewCliExecutor(boilerplatePath, false), Options{Parallel: parallel})
+
+	jobs, err := tp.BuildProcessingJobs()
+	if err != nil {
+		t.Fatalf("BuildProcessingJobs() error = %v", err)
+	}
+	jobs, err = orderJobs(jobs)
+	if err != nil {
+		t.Fatalf("orderJobs() error = %v", err)
+	}
+
+	results, stopped := orch.runJobs(context.Background(), jobs)
+	if stopped {
+		t.Fatalf("Expected all jobs to run, log:\n%s", logBuf.String())
+	}
+	return results
+}
+
+func TestRunJobs_Parallel(t *testing.T) {
+	t.Run("independent templates run concurrently", func(t *testing.T) {
+		cfg := &config.ComposeConfig{
+			Templates: map[string]config.Template{
+				"a": {TemplateURL: "https://example.com/a", OutputFolder: "./a"},
+				"b": {TemplateURL: "https://example.com/b", OutputFolder: "./b"},
+				"c": {TemplateURL: "https://example.com/c", OutputFolder: "./c"},
+			},
+		}
+		path, logPath := timelineBoilerplate(t)
+
+		results := runParallel(t, cfg, path, 3)
+
+		timeline := readTimeline(t, logPath)
+		for _, line := range timeline[:3] {
+			if !strings.HasPrefix(line, "start ") {
+				t.Fatalf("Expected all templates to start before any finished, got %v", timeline)
+			}
+		}
+
+		// Results are reported in job order regardless of completion order
+		if names := resultNames(results); strings.Join(names, ",") != "a,b,c" {
+			t.Errorf("Expected results in job order, got %v", names)
+		}
+	})
+
+	t.Run("dependencies and overlapping outputs are serialized", func(t *testing.T) {
+		cfg := &config.ComposeConfig{
+			Templates: map[string]config.Template{
+				"1-app":   {TemplateURL: "https://example.com/app", OutputFolder: "./app"},
+				"2-ci":    {TemplateURL: "https://example.com/ci", OutputFolder: "./app/ci"},
+				"3-other": {TemplateURL: "https://example.com/other", OutputFolder: "./other", DependsOn: []string{"2-ci"}},
+			},
+		}
+		path, logPath := timelineBoilerplate(t)
+
+		runParallel(t, cfg, path, 3)
+
+		expected := []string{"start app", "end app", "start ci", "end ci", "start other", "end other"}
+		if timeline := readTimeline(t, logPath); strings.Join(timeline, ",") != strings.Join(expected, ",") {
+			t.Errorf("Expected timeline %v, got %v", expected, timeline)
+		}
+	})
+}
+
+func resultNames(results []executor.ExecutionResult) []string {
+	names := make([]string, 0, len(results))
+	for _, result := range results {
+		names = append(names, result.TemplateName)
+	}
+	return names
+}
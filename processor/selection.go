@@ -0,0 +1,217 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	"boilerplate-compose/config"
+)
+
+// Select restricts the templates that BuildProcessingJobs returns. Each
+// pattern is a template name or a glob such as "svc-*"; without patterns
+// every template is selected. Templates matching an exclude pattern are left
+// out. The templates selected templates depend on are added as well, unless
+// they are excluded or SetNoDeps is used. Templates selected by their exact
+// name run even if their profiles are not active.
+func (tp *TemplateProcessor) Select(patterns, exclude []string) error {
+	names := tp.config.TemplateNames()
+
+	named := make(map[string]bool)
+	for _, pattern := range patterns {
+		if _, exists := tp.config.Templates[pattern]; exists {
+			named[pattern] = true
+		}
+	}
+
+	selected := make(map[string]bool, len(names))
+	if len(patterns) == 0 {
+		for _, name := range names {
+			selected[name] = true
+		}
+	} else {
+		matched, err := matchTemplates(names, patterns)
+		if err != nil {
+			return err
+		}
+		selected = matched
+	}
+
+	excluded, err := matchTemplates(names, exclude)
+	if err != nil {
+		return err
+	}
+	for name := range excluded {
+		delete(selected, name)
+	}
+
+	if len(selected) == 0 {
+		return fmt.Errorf("no templates selected")
+	}
+
+	for name := range named {
+		if !selected[name] {
+			delete(named, name)
+		}
+	}
+
+	tp.selected = selected
+	tp.named = named
+	tp.excluded = excluded
+	return nil
+}
+
+// SetNoDeps controls whether the dependencies of selected templates are
+// left out unless they are selected themselves.
+func (tp *TemplateProcessor) SetNoDeps(noDeps bool) {
+	tp.noDeps = noDeps
+}
+
+// SetProfiles sets the active profiles. Templates with profiles are only
+// processed when one of them is active. "*" enables all profiles.
+func (tp *TemplateProcessor) SetProfiles(profiles []string) {
+	tp.profiles = profiles
+}
+
+// enabled reports whether a template is selected and has an active profile
+func (tp *TemplateProcessor) enabled(name string, template config.Template) bool {
+	if tp.selected != nil && !tp.selected[name] {
+		return false
+	}
+	return tp.named[name] || template.Enabled(tp.profiles)
+}
+
+// enabledTemplates returns the names of the templates to process: the
+// selected templates with an active profile, and every template they depend
+// on, whatever its profiles, as Docker Compose does. A dependency that is
+// left out by -exclude or SetNoDeps is reported, since the templates
+// depending on it then run without it.
+func (tp *TemplateProcessor) enabledTemplates() map[string]bool {
+	enabled := make(map[string]bool)
+	var pending []string
+	for _, name := range tp.config.TemplateNames() {
+		if tp.enabled(name, tp.config.Templates[name]) {
+			enabled[name] = true
+			pending = append(pending, name)
+		}
+	}
+
+	for len(pending) > 0 {
+		name := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		for _, dep := range tp.config.Templates[name].DependsOn {
+			if _, exists := tp.config.Templates[dep]; !exists || enabled[dep] {
+				continue
+			}
+			if tp.excluded[dep] || (tp.noDeps && tp.selected != nil && !tp.selected[dep]) {
+				log.Printf("Warning: template '%s' depends on '%s', which is not selected and will not run", name, dep)
+				continue
+			}
+			enabled[dep] = true
+			pending = append(pending, dep)
+		}
+	}
+
+	return enabled
+}
+
+// matchTemplates returns the names matching any of the patterns. A pattern
+// that matches nothing is an error, with suggestions for misspelled names.
+func matchTemplates(names, patterns []string) (map[string]bool, error) {
+	matched := make(map[string]bool)
+
+	for _, pattern := range patterns {
+		found := false
+		for _, name := range names {
+			ok, err := path.Match(pattern, name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid template pattern '%s': %w", pattern, err)
+			}
+			if ok {
+				matched[name] = true
+				found = true
+			}
+		}
+
+		if found {
+			continue
+		}
+		if isGlob(pattern) {
+			return nil, fmt.Errorf("no templates match '%s'", pattern)
+		}
+		return nil, unknownTemplateError(pattern, names)
+	}
+
+	return matched, nil
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// unknownTemplateError reports a template name that does not exist, listing
+// the closest existing names when there are any.
+func unknownTemplateError(name string, names []string) error {
+	suggestions := similarNames(name, names)
+	if len(suggestions) == 0 {
+		return fmt.Errorf("unknown template '%s'", name)
+	}
+	return fmt.Errorf("unknown template '%s' (did you mean '%s'?)", name, strings.Join(suggestions, "', '"))
+}
+
+// similarNames returns up to three names within a small edit distance of
+// name, closest first. Names at the same distance keep their order.
+func similarNames(name string, names []string) []string {
+	maxDistance := len(name) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+	var candidates []candidate
+	for _, other := range names {
+		d := editDistance(strings.ToLower(name), strings.ToLower(other))
+		if d <= maxDistance || strings.Contains(other, name) {
+			candidates = append(candidates, candidate{other, d})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	var result []string
+	for i := 0; i < len(candidates) && i < 3; i++ {
+		result = append(result, candidates[i].name)
+	}
+	return result
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
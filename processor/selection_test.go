@@ -0,0 +1,220 @@
+package processor
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"boilerplate-compose/config"
+)
+
+func selectionConfig() *config.ComposeConfig {
+	templates := map[string]config.Template{}
+	for _, name := range []string{"frontend", "backend", "svc-api", "svc-worker"} {
+		templates[name] = config.Template{TemplateURL: "./" + name, OutputFolder: "./" + name}
+	}
+	return &config.ComposeConfig{Templates: templates}
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		exclude  []string
+		expected []string
+	}{
+		{"everything by default", nil, nil, []string{"backend", "frontend", "svc-api", "svc-worker"}},
+		{"single name", []string{"frontend"}, nil, []string{"frontend"}},
+		{"glob", []string{"svc-*"}, nil, []string{"svc-api", "svc-worker"}},
+		{"names and globs", []string{"svc-w*", "backend"}, nil, []string{"backend", "svc-worker"}},
+		{"exclude from all", nil, []string{"svc-*"}, []string{"backend", "frontend"}},
+		{"exclude from selection", []string{"svc-*"}, []string{"svc-api"}, []string{"svc-worker"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := NewTemplateProcessor(selectionConfig(), "/test/config.yaml")
+			if err := tp.Select(tt.patterns, tt.exclude); err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+
+			jobs, err := tp.BuildProcessingJobs()
+			if err != nil {
+				t.Fatalf("BuildProcessingJobs() error = %v", err)
+			}
+			if got := jobNames(jobs); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("selected %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSelectErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		exclude  []string
+		expected string
+	}{
+		{"misspelled name", []string{"fronted"}, nil, "unknown template 'fronted' (did you mean 'frontend'?)"},
+		{"several suggestions", []string{"svc"}, nil, "unknown template 'svc' (did you mean 'svc-api', 'svc-worker'?"},
+		{"no suggestion", []string{"database"}, nil, "unknown template 'database'"},
+		{"unmatched glob", []string{"db-*"}, nil, "no templates match 'db-*'"},
+		{"unknown exclude", nil, []string{"backnd"}, "unknown template 'backnd' (did you mean 'backend'?)"},
+		{"invalid glob", []string{"svc-["}, nil, "invalid template pattern 'svc-['"},
+		{"everything excluded", []string{"frontend"}, []string{"front*"}, "no templates selected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := NewTemplateProcessor(selectionConfig(), "/test/config.yaml")
+			err := tp.Select(tt.patterns, tt.exclude)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected error containing %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"frontend", "fronted", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.expected {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	cfg := selectionConfig()
+	docs := cfg.Templates["frontend"]
+	docs.Profiles = []string{"docs"}
+	cfg.Templates["frontend"] = docs
+	worker := cfg.Templates["svc-worker"]
+	worker.Profiles = []string{"workers", "ci"}
+	cfg.Templates["svc-worker"] = worker
+
+	tests := []struct {
+		name     string
+		profiles []string
+		patterns []string
+		expected []string
+	}{
+		{"no active profiles", nil, nil, []string{"backend", "svc-api"}},
+		{"one profile", []string{"ci"}, nil, []string{"backend", "svc-api", "svc-worker"}},
+		{"all profiles", []string{"*"}, nil, []string{"backend", "frontend", "svc-api", "svc-worker"}},
+		{"named template ignores profiles", nil, []string{"frontend", "backend"}, []string{"backend", "frontend"}},
+		{"glob respects profiles", nil, []string{"svc-*"}, []string{"svc-api"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := NewTemplateProcessor(cfg, "/test/config.yaml")
+			if err := tp.Select(tt.patterns, nil); err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+			tp.SetProfiles(tt.profiles)
+
+			jobs, err := tp.BuildProcessingJobs()
+			if err != nil {
+				t.Fatalf("BuildProcessingJobs() error = %v", err)
+			}
+			if got := jobNames(jobs); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("enabled %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProfilesEnableDependencies(t *testing.T) {
+	cfg := &config.ComposeConfig{
+		Templates: map[string]config.Template{
+			"site":   {TemplateURL: "./site", OutputFolder: "./site", DependsOn: []string{"docs"}},
+			"docs":   {TemplateURL: "./docs", OutputFolder: "./docs", DependsOn: []string{"assets"}, Profiles: []string{"docs"}},
+			"assets": {TemplateURL: "./assets", OutputFolder: "./assets", Profiles: []string{"assets"}},
+			"extra":  {TemplateURL: "./extra", OutputFolder: "./extra", Profiles: []string{"extra"}},
+		},
+	}
+
+	tp := NewTemplateProcessor(cfg, "/test/config.yaml")
+	jobs, err := tp.ExecutionPlan()
+	if err != nil {
+		t.Fatalf("ExecutionPlan() error = %v", err)
+	}
+
+	expected := []string{"assets", "docs", "site"}
+	if got := jobNames(jobs); !reflect.DeepEqual(got, expected) {
+		t.Errorf("enabled %v, want %v", got, expected)
+	}
+}
+
+func TestSelectDependencies(t *testing.T) {
+	cfg := &config.ComposeConfig{
+		Templates: map[string]config.Template{
+			"scaffold": {TemplateURL: "./scaffold", OutputFolder: "./scaffold"},
+			"ci":       {TemplateURL: "./ci", OutputFolder: "./ci", DependsOn: []string{"scaffold"}},
+			"deploy":   {TemplateURL: "./deploy", OutputFolder: "./deploy", DependsOn: []string{"ci"}},
+			"docs":     {TemplateURL: "./docs", OutputFolder: "./docs"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		exclude  []string
+		noDeps   bool
+		expected []string
+		warning  string
+	}{
+		{"dependencies are added", []string{"ci"}, nil, false, []string{"scaffold", "ci"}, ""},
+		{"dependencies are added transitively", []string{"deploy"}, nil, false, []string{"scaffold", "ci", "deploy"}, ""},
+		{"no-deps leaves them out", []string{"ci"}, nil, true, []string{"ci"}, "template 'ci' depends on 'scaffold', which is not selected"},
+		{"no-deps keeps selected dependencies", []string{"ci", "scaffold"}, nil, true, []string{"scaffold", "ci"}, ""},
+		{"excluded dependencies are left out", []string{"ci"}, []string{"scaffold"}, false, []string{"ci"}, "template 'ci' depends on 'scaffold', which is not selected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logBuf bytes.Buffer
+			log.SetOutput(&logBuf)
+			defer log.SetOutput(os.Stderr)
+
+			tp := NewTemplateProcessor(cfg, "/test/config.yaml")
+			if err := tp.Select(tt.patterns, tt.exclude); err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+			tp.SetNoDeps(tt.noDeps)
+
+			jobs, err := tp.ExecutionPlan()
+			if err != nil {
+				t.Fatalf("ExecutionPlan() error = %v", err)
+			}
+			if got := jobNames(jobs); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("selected %v, want %v", got, tt.expected)
+			}
+
+			if tt.warning == "" {
+				if logBuf.Len() > 0 {
+					t.Errorf("expected no warning, got %q", logBuf.String())
+				}
+			} else if !strings.Contains(logBuf.String(), tt.warning) {
+				t.Errorf("expected warning %q, got %q", tt.warning, logBuf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package processor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"boilerplate-compose/config"
+	"boilerplate-compose/lock"
+)
+
+// StateExtension names the hidden file, next to the compose file, that
+// records what every template was last rendered from. It replaces the
+// extension of the compose file, so boilerplate-compose.yaml keeps its state
+// in .boilerplate-compose.state.
+const StateExtension = ".state"
+
+// stateVersion is the version of the state file format. A state file with
+// another version is ignored, so every template runs once again.
+const stateVersion = 1
+
+// runState is the content of the state file.
+type runState struct {
+	Version   int                      `json:"version"`
+	Templates map[string]templateState `json:"templates"`
+}
+
+// templateState records the last successful run of a template.
+type templateState struct {
+	// Fingerprint covers every input of the template; see fingerprint.
+	Fingerprint string `json:"fingerprint"`
+	// Output is the hash of the output folder after the run.
+	Output    string    `json:"output"`
+	Completed time.Time `json:"completed"`
+}
+
+// statePath returns the path of the state file that belongs to a compose
+// file. It is named after the compose file, so compose files that share a
+// directory each keep their own state.
+func statePath(configPath string) string {
+	name := filepath.Base(configPath)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return filepath.Join(filepath.Dir(configPath), "."+name+StateExtension)
+}
+
+// loadState reads the state file. A missing file, or one written by another
+// version, gives an empty state.
+func loadState(path string) (*runState, error) {
+	state := &runState{Version: stateVersion, Templates: make(map[string]templateState)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var loaded runState
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return state, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	if loaded.Version != stateVersion || loaded.Templates == nil {
+		return state, nil
+	}
+	return &loaded, nil
+}
+
+func (s *runState) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state file: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+// RevisionResolver is implemented by executors that fetch remote template
+// sources themselves. Revision identifies the exact content a template URL
+// resolves to, such as a commit, so incremental runs can tell when a remote
+// template changed.
+type RevisionResolver interface {
+	Revision(ctx context.Context, templateURL string) (string, error)
+}
+
+// fingerprint returns a hash of everything that determines what a job
+// renders: the boilerplate arguments, which hold the template URL, output
+// folder, variables and flags, together with the content of the var files
+// and of the template itself. Local templates are covered by their content
+// and remote ones by their revision, as given by resolver. Remote templates
+// without a revision cannot be fingerprinted, since what they resolve to is
+// only known to boilerplate.
+func fingerprint(ctx context.Context, job ProcessingJob, resolver RevisionResolver) (string, error) {
+	var source string
+	if config.IsLocalTemplateURL(job.Template.TemplateURL) {
+		hash, err := lock.HashDir(job.Template.TemplateURL)
+		if err != nil {
+			return "", err
+		}
+		source = hash
+	} else {
+		if resolver == nil {
+			return "", fmt.Errorf("the revision of %s is not known", job.Template.TemplateURL)
+		}
+		revision, err := resolver.Revision(ctx, job.Template.TemplateURL)
+		if err != nil {
+			return "", err
+		}
+		source = revision
+	}
+
+	hash := sha256.New()
+	for _, arg := range job.Args {
+		fmt.Fprintf(hash, "%d:%s\x00", len(arg), arg)
+	}
+	fmt.Fprintf(hash, "template:%s\x00", source)
+
+	for i := 0; i+1 < len(job.Args); i++ {
+		if job.Args[i] != "--var-file" {
+			continue
+		}
+		data, err := os.ReadFile(job.Args[i+1])
+		if err != nil {
+			return "", fmt.Errorf("failed to read var file: %w", err)
+		}
+		fmt.Fprintf(hash, "var-file:%d:", len(data))
+		hash.Write(data)
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// outputHash returns a hash of the files in an output folder. The state and
+// lock files next to the compose file are left out, since an output folder
+// may contain the compose file's directory and the state file changes on
+// every run.
+func outputHash(dir, configPath string) (string, error) {
+	return lock.HashDirExcept(dir, statePath(configPath), lock.PathFor(configPath))
+}
@@ -0,0 +1,262 @@
+package processor
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"boilerplate-compose/config"
+	"boilerplate-compose/executor"
+)
+
+// ownFiles makes every template write a file named after itself
+func ownFiles(names ...string) map[string]map[string]string {
+	files := make(map[string]map[string]string, len(names))
+	for _, name := range names {
+		files[name] = map[string]string{name + ".txt": name}
+	}
+	return files
+}
+
+func TestOrchestrator_Incremental(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("templates/base/boilerplate.yml", "base")
+	write("templates/app/boilerplate.yml", "app")
+	write("templates/docs/boilerplate.yml", "docs")
+	write("docs.yml", "title: Docs")
+
+	cfg := &config.ComposeConfig{
+		Templates: map[string]config.Template{
+			"base": {TemplateURL: filepath.Join(dir, "templates/base"), OutputFolder: "./base"},
+			"app":  {TemplateURL: filepath.Join(dir, "templates/app"), OutputFolder: "./app", DependsOn: []string{"base"}},
+			"docs": {
+				TemplateURL:  filepath.Join(dir, "templates/docs"),
+				OutputFolder: "./docs",
+				VarFile:      filepath.Join(dir, "docs.yml"),
+				Vars:         map[string]string{"title": "Docs"},
+			},
+		},
+	}
+	configPath := filepath.Join(dir, "compose.yaml")
+
+	process := func(opts Options) ([]string, error) {
+		t.Helper()
+		exec := &fakeExecutor{files: ownFiles("base", "app", "docs")}
+		opts.Incremental = true
+		err := NewOrchestratorWithOptions(NewTemplateProcessor(cfg, configPath), exec, opts).Process()
+		sort.Strings(exec.ran)
+		return exec.ran, err
+	}
+	expectRan := func(step string, opts Options, expected ...string) {
+		t.Helper()
+		ran, err := process(opts)
+		if err != nil {
+			t.Fatalf("%s: Process() error = %v", step, err)
+		}
+		if fmt.Sprint(ran) != fmt.Sprint(expected) {
+			t.Errorf("%s: ran %v, want %v", step, ran, expected)
+		}
+	}
+
+	expectRan("first run", Options{}, "app", "base", "docs")
+	if _, err := os.Stat(filepath.Join(dir, ".compose.state")); err != nil {
+		t.Fatalf("expected a state file next to the compose file: %v", err)
+	}
+	expectRan("unchanged", Options{})
+
+	write("templates/base/boilerplate.yml", "base v2")
+	expectRan("template changed, with a dependent", Options{}, "app", "base")
+
+	write("docs.yml", "title: New docs")
+	expectRan("var file changed", Options{}, "docs")
+
+	docs := cfg.Templates["docs"]
+	docs.Vars = map[string]string{"title": "Other"}
+	cfg.Templates["docs"] = docs
+	expectRan("vars changed", Options{}, "docs")
+
+	write("docs/docs.txt", "edited by hand")
+	expectRan("output changed", Options{}, "docs")
+
+	write("docs/extra.txt", "added by hand")
+	expectRan("output file added", Options{}, "docs")
+
+	expectRan("force", Options{Force: true}, "app", "base", "docs")
+	expectRan("force template", Options{ForceTemplates: []string{"app"}}, "app")
+	expectRan("dry run", Options{DryRun: true})
+	expectRan("unchanged after forcing", Options{})
+
+	t.Run("unknown forced template", func(t *testing.T) {
+		if _, err := process(Options{ForceTemplates: []string{"nope"}}); err == nil {
+			t.Error("expected an error for an unknown template")
+		}
+	})
+
+	t.Run("failed templates run again", func(t *testing.T) {
+		if err := os.RemoveAll(filepath.Join(dir, "app")); err != nil {
+			t.Fatal(err)
+		}
+		exec := &fakeExecutor{files: ownFiles("base", "app", "docs"), fail: map[string]bool{"app": true}}
+		orch := NewOrchestratorWithOptions(NewTemplateProcessor(cfg, configPath), exec, Options{Incremental: true})
+		if err := orch.Process(); err == nil {
+			t.Fatal("expected the run to fail")
+		}
+		expectRan("after failure", Options{}, "app")
+	})
+}
+
+func TestOrchestrator_IncrementalRemoteTemplates(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	dir := t.TempDir()
+	cfg := &config.ComposeConfig{
+		Templates: map[string]config.Template{
+			"remote": {TemplateURL: "github.com/org/templates//web?ref=main", OutputFolder: "./remote"},
+		},
+	}
+
+	process := func(exec executor.Executor) {
+		t.Helper()
+		orch := NewOrchestratorWithOptions(NewTemplateProcessor(cfg, filepath.Join(dir, "compose.yaml")), exec, Options{Incremental: true})
+		if err := orch.Process(); err != nil {
+			t.Fatalf("Process() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		revision string
+		runs     bool
+	}{
+		{"first run", "abc", true},
+		{"same revision", "abc", false},
+		{"new revision", "def", true},
+	}
+	for _, tt := range tests {
+		exec := &fakeExecutor{files: ownFiles("remote"), revision: tt.revision}
+		process(exec)
+		if ran := len(exec.ran) > 0; ran != tt.runs {
+			t.Errorf("%s: ran = %v, want %v", tt.name, ran, tt.runs)
+		}
+	}
+
+	// Without a revision the template cannot be checked for changes
+	exec := &fakeExecutor{files: ownFiles("remote")}
+	process(exec)
+	process(exec)
+	if len(exec.ran) != 2 {
+		t.Errorf("expected a template without a revision to always run, ran %d times", len(exec.ran))
+	}
+}
+
+func TestOrchestrator_IncrementalRootOutputFolder(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	dir := t.TempDir()
+	templateDir := filepath.Join(dir, "templates", "root")
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "boilerplate.yml"), []byte("root"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The output folder holds the compose file, and so the state file
+	cfg := &config.ComposeConfig{
+		Templates: map[string]config.Template{
+			"root": {TemplateURL: templateDir, OutputFolder: "."},
+		},
+	}
+	configPath := filepath.Join(dir, "compose.yaml")
+
+	var runs int
+	for i := 0; i < 3; i++ {
+		exec := &fakeExecutor{files: ownFiles("root")}
+		orch := NewOrchestratorWithOptions(NewTemplateProcessor(cfg, configPath), exec, Options{Incremental: true})
+		if err := orch.Process(); err != nil {
+			t.Fatalf("Process() error = %v", err)
+		}
+		runs += len(exec.ran)
+	}
+	if runs != 1 {
+		t.Errorf("expected the template to run once in three runs, ran %d times", runs)
+	}
+}
+
+func TestOrchestrator_IncrementalSharedDirectory(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	dir := t.TempDir()
+	var configs []*config.ComposeConfig
+	for _, name := range []string{"a", "b"} {
+		templateDir := filepath.Join(dir, "templates", name)
+		if err := os.MkdirAll(templateDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(templateDir, "boilerplate.yml"), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// Both compose files name their template app
+		configs = append(configs, &config.ComposeConfig{
+			Templates: map[string]config.Template{
+				"app": {TemplateURL: templateDir, OutputFolder: "./" + name},
+			},
+		})
+	}
+
+	run := func(cfg *config.ComposeConfig, configPath string) int {
+		t.Helper()
+		exec := &fakeExecutor{files: ownFiles("app")}
+		orch := NewOrchestratorWithOptions(NewTemplateProcessor(cfg, configPath), exec, Options{Incremental: true})
+		if err := orch.Process(); err != nil {
+			t.Fatalf("Process() error = %v", err)
+		}
+		return len(exec.ran)
+	}
+
+	aPath, bPath := filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yaml")
+	run(configs[0], aPath)
+	run(configs[1], bPath)
+	if ran := run(configs[0], aPath); ran != 0 {
+		t.Errorf("expected a.yaml to be up to date after running b.yaml, ran %d templates", ran)
+	}
+	if ran := run(configs[1], bPath); ran != 0 {
+		t.Errorf("expected b.yaml to be up to date, ran %d templates", ran)
+	}
+}
+
+func TestStatePath(t *testing.T) {
+	tests := []struct {
+		configPath string
+		expected   string
+	}{
+		{"boilerplate-compose.yaml", ".boilerplate-compose.state"},
+		{filepath.Join("tests", "complex-test.yml"), filepath.Join("tests", ".complex-test.state")},
+	}
+
+	for _, tt := range tests {
+		if got := statePath(tt.configPath); got != tt.expected {
+			t.Errorf("statePath(%q) = %q, want %q", tt.configPath, got, tt.expected)
+		}
+	}
+}
@@ -2,14 +2,31 @@ package processor
 
 import (
 	"fmt"
-	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
 
 	"boilerplate-compose/config"
+	"boilerplate-compose/executor"
 )
 
 type TemplateProcessor struct {
 	config     *config.ComposeConfig
 	configPath string
+	// selected holds the names of the templates to process, or nil for all
+	// of them. It is set by Select.
+	selected map[string]bool
+	// named holds the templates selected by their exact name, which run
+	// even when none of their profiles is active.
+	named map[string]bool
+	// profiles are the active profiles, set by SetProfiles.
+	profiles []string
+	// excluded holds the templates left out with an exclude pattern, which
+	// are not added even as dependencies.
+	excluded map[string]bool
+	// noDeps leaves out the dependencies of selected templates. It is set by
+	// SetNoDeps.
+	noDeps bool
 }
 
 func NewTemplateProcessor(cfg *config.ComposeConfig, configPath string) *TemplateProcessor {
@@ -23,27 +40,60 @@ type ProcessingJob struct {
 	Name     string
 	Template config.Template
 	Args     []string
+	// OutputPath is the output folder resolved against the config file.
+	OutputPath string
+	// Retry is the retry policy of the template, or of the compose file
+	// when the template has none.
+	Retry executor.RetryPolicy
 }
 
 func (tp *TemplateProcessor) BuildProcessingJobs() ([]ProcessingJob, error) {
 	var jobs []ProcessingJob
 
-	for name, template := range tp.config.Templates {
+	enabled := tp.enabledTemplates()
+	for _, name := range tp.config.TemplateNames() {
+		if !enabled[name] {
+			continue
+		}
+		template := tp.config.Templates[name]
+
 		args, err := tp.buildBoilerplateArgs(template)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build args for template '%s': %w", name, err)
 		}
 
+		retry := template.Retry
+		if retry == nil {
+			retry = tp.config.Retry
+		}
+		policy, err := retryPolicy(retry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry policy for template '%s': %w", name, err)
+		}
+
 		jobs = append(jobs, ProcessingJob{
-			Name:     name,
-			Template: template,
-			Args:     args,
+			Name:       name,
+			Template:   template,
+			Args:       args,
+			OutputPath: tp.resolveOutputPath(template.OutputFolder),
+			Retry:      policy,
 		})
 	}
 
 	return jobs, nil
 }
 
+// ExecutionPlan returns the processing jobs in the order they will run,
+// with every template after the templates it depends on.
+func (tp *TemplateProcessor) ExecutionPlan() ([]ProcessingJob, error) {
+	jobs, err := tp.BuildProcessingJobs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build processing jobs: %w", err)
+	}
+
+	return orderJobs(jobs)
+}
+
 func (tp *TemplateProcessor) buildBoilerplateArgs(template config.Template) ([]string, error) {
 	var args []string
 
@@ -54,9 +104,9 @@ func (tp *TemplateProcessor) buildBoilerplateArgs(template config.Template) ([]s
 	outputPath := tp.resolveOutputPath(template.OutputFolder)
 	args = append(args, "--output-folder", outputPath)
 
-	// Add variables
-	for key, value := range template.Vars {
-		args = append(args, "--var", fmt.Sprintf("%s=%s", key, value))
+	// Add variables in a stable order
+	for _, key := range sortedKeys(template.Vars) {
+		args = append(args, "--var", fmt.Sprintf("%s=%s", key, template.Vars[key]))
 	}
 
 	// Add var-file(s)
@@ -103,14 +153,41 @@ func (tp *TemplateProcessor) buildBoilerplateArgs(template config.Template) ([]s
 }
 
 func (tp *TemplateProcessor) resolveOutputPath(outputFolder string) string {
-	// If path is already absolute, return as-is
-	if filepath.IsAbs(outputFolder) {
-		return outputFolder
-	}
-	
-	// Get directory containing the config file
-	configDir := filepath.Dir(tp.configPath)
-	
-	// Join config directory with relative output path
-	return filepath.Join(configDir, outputFolder)
-}
\ No newline at end of file
+	return config.ResolveOutputFolder(tp.configPath, outputFolder)
+}
+
+// retryPolicy converts a retry configuration into the policy used when
+// running the template. A nil configuration means a single attempt.
+func retryPolicy(retry *config.RetryConfig) (executor.RetryPolicy, error) {
+	if retry == nil {
+		return executor.RetryPolicy{Attempts: 1}, nil
+	}
+
+	policy := executor.RetryPolicy{
+		Attempts:    retry.Attempts,
+		Backoff:     time.Duration(retry.Backoff),
+		OnExitCodes: retry.OnExitCodes,
+	}
+	if retry.Backoff == 0 {
+		policy.Backoff = time.Duration(config.DefaultRetryBackoff)
+	}
+
+	for _, pattern := range retry.OnStderrMatch {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return executor.RetryPolicy{}, err
+		}
+		policy.OnStderrMatch = append(policy.OnStderrMatch, re)
+	}
+
+	return policy, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -4,8 +4,10 @@ import (
 	"reflect"
 	"testing"
 	"path/filepath"
+	"time"
 
 	"boilerplate-compose/config"
+	"gopkg.in/yaml.v3"
 )
 
 func TestNewTemplateProcessor(t *testing.T) {
@@ -229,4 +231,80 @@ func TestResolveOutputPath(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestBuildProcessingJobsOrder(t *testing.T) {
+	data := `
+templates:
+  scaffold:
+    template-url: "https://github.com/example/scaffold"
+    output-folder: "./app"
+  ci:
+    template-url: "https://github.com/example/ci"
+    output-folder: "./app/.github"
+  docs:
+    template-url: "https://github.com/example/docs"
+    output-folder: "./app/docs"
+    vars:
+      zeta: "z"
+      alpha: "a"
+      mid: "m"
+`
+	var cfg config.ComposeConfig
+	if err := yaml.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("Failed to unmarshal YAML: %v", err)
+	}
+
+	tp := NewTemplateProcessor(&cfg, "/test/config.yaml")
+	for i := 0; i < 5; i++ {
+		jobs, err := tp.BuildProcessingJobs()
+		if err != nil {
+			t.Fatalf("BuildProcessingJobs() error = %v", err)
+		}
+
+		var names []string
+		for _, job := range jobs {
+			names = append(names, job.Name)
+		}
+		if !reflect.DeepEqual(names, []string{"scaffold", "ci", "docs"}) {
+			t.Fatalf("Expected jobs in declaration order, got %v", names)
+		}
+
+		expectedArgs := []string{
+			"--template-url", "https://github.com/example/docs",
+			"--output-folder", "/test/app/docs",
+			"--var", "alpha=a",
+			"--var", "mid=m",
+			"--var", "zeta=z",
+		}
+		if !reflect.DeepEqual(jobs[2].Args, expectedArgs) {
+			t.Fatalf("Expected args %v, got %v", expectedArgs, jobs[2].Args)
+		}
+	}
+}
+
+func TestBuildProcessingJobsRetryPolicy(t *testing.T) {
+	cfg := &config.ComposeConfig{
+		Retry: &config.RetryConfig{Attempts: 2},
+		Templates: map[string]config.Template{
+			"default": {TemplateURL: "./a", OutputFolder: "./a"},
+			"own": {TemplateURL: "./b", OutputFolder: "./b", Retry: &config.RetryConfig{
+				Attempts:      5,
+				Backoff:       config.Duration(3 * time.Second),
+				OnStderrMatch: []string{"timeout"},
+			}},
+		},
+	}
+
+	jobs, err := NewTemplateProcessor(cfg, "/test/config.yaml").BuildProcessingJobs()
+	if err != nil {
+		t.Fatalf("BuildProcessingJobs() error = %v", err)
+	}
+
+	if policy := jobs[0].Retry; policy.Attempts != 2 || policy.Backoff != time.Duration(config.DefaultRetryBackoff) {
+		t.Errorf("expected the compose file's retry policy, got %+v", policy)
+	}
+	own := jobs[1].Retry
+	if own.Attempts != 5 || own.Backoff != 3*time.Second || len(own.OnStderrMatch) != 1 || !own.OnStderrMatch[0].MatchString("i/o timeout") {
+		t.Errorf("expected the template's retry policy, got %+v", own)
+	}
+}